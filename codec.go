@@ -0,0 +1,508 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codec reads and writes request and response bodies of a single media type
+type Codec interface {
+	Decode(r io.Reader, v interface{}) error
+	Encode(w io.Writer, v interface{}) error
+}
+
+type registeredCodec struct {
+	mediaType string
+	codec     Codec
+}
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = []registeredCodec{
+		{"application/json", jsonCodec{}},
+		{"application/xml", xmlCodec{}},
+		{"text/xml", xmlCodec{}},
+		{"application/x-www-form-urlencoded", formCodec{}},
+	}
+)
+
+// RegisterCodec adds a codec for the media type, or replaces the one already
+// registered for it. Requests without a Content-Type use JSON, as do
+// responses to requests that accept anything, unless they prefer one of the
+// other types they list.
+func RegisterCodec(mediaType string, codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	for i, registered := range codecs {
+		if registered.mediaType == mediaType {
+			codecs[i].codec = codec
+			return
+		}
+	}
+	codecs = append(codecs, registeredCodec{mediaType, codec})
+}
+
+// MediaTypes lists the media types which have a codec registered, in the
+// order they were registered
+func MediaTypes() []string {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	types := make([]string, len(codecs))
+	for i, registered := range codecs {
+		types[i] = registered.mediaType
+	}
+	return types
+}
+
+func findCodec(mediaType string) Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	for _, registered := range codecs {
+		if registered.mediaType == mediaType {
+			return registered.codec
+		}
+	}
+	return nil
+}
+
+// DecodeBody decodes the request body into v with the codec for the
+// request's Content-Type
+func DecodeBody(req *http.Request, v interface{}) error {
+	mediaType := "application/json"
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return Errorf(http.StatusUnsupportedMediaType, "invalid content type '%s'", contentType)
+		}
+		mediaType = parsed
+	}
+
+	if mediaType == "multipart/form-data" {
+		return decodeMultipart(req, v)
+	}
+
+	codec := findCodec(mediaType)
+	if codec == nil {
+		return Errorf(http.StatusUnsupportedMediaType, "unsupported content type '%s'", mediaType)
+	}
+
+	if err := codec.Decode(req.Body, v); err != nil {
+		return Errorf(http.StatusBadRequest, "decoding %s: %s", mediaType, err.Error())
+	}
+
+	return nil
+}
+
+// ResponseCodec is the codec chosen to write a response body, and the media
+// type it writes. The other acceptable codecs are kept in case the body can't
+// be written with the chosen one.
+type ResponseCodec struct {
+	MediaType string
+	Codec     Codec
+
+	fallbacks []registeredCodec
+}
+
+// NegotiateCodec chooses the codec for the most preferred media type in the
+// request's Accept header, or returns a 406 error if none of them have one.
+// A range like */* that covers JSON chooses JSON, unless a listed type with a
+// codec is preferred over it. It's called before the handler, so a request
+// that can't be answered doesn't have any effects.
+func NegotiateCodec(req *http.Request) (ResponseCodec, error) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	acceptable := []registeredCodec{}
+	added := map[string]bool{}
+	add := func(registered registeredCodec) {
+		if !added[registered.mediaType] {
+			added[registered.mediaType] = true
+			acceptable = append(acceptable, registered)
+		}
+	}
+
+	for _, accepted := range acceptedMediaTypes(req.Header.Get("Accept")) {
+		//ranges try JSON before the other codecs they cover
+		if mediaTypeMatches(accepted, "application/json") {
+			for _, registered := range codecs {
+				if registered.mediaType == "application/json" {
+					add(registered)
+				}
+			}
+		}
+		for _, registered := range codecs {
+			if mediaTypeMatches(accepted, registered.mediaType) {
+				add(registered)
+			}
+		}
+	}
+
+	if len(acceptable) == 0 {
+		return ResponseCodec{}, Errorf(
+			http.StatusNotAcceptable,
+			"none of the accepted media types '%s' are supported",
+			req.Header.Get("Accept"),
+		)
+	}
+
+	return ResponseCodec{
+		MediaType: acceptable[0].mediaType,
+		Codec:     acceptable[0].codec,
+		fallbacks: acceptable[1:],
+	}, nil
+}
+
+// EncodeBody writes v as the response body with the codec given. If that
+// codec can't encode v, like XML for a map, the next acceptable codec is
+// tried, and if none of them can a 406 error is returned, unless JSON was
+// acceptable and failed too. A code other than 0
+// is sent as the response's status, and statuses which can't have a body are
+// sent without one.
+func EncodeBody(res http.ResponseWriter, code int, codec ResponseCodec, v interface{}) error {
+	if code != 0 && !bodyAllowed(code) {
+		res.WriteHeader(code)
+		return nil
+	}
+
+	candidates := append([]registeredCodec{{codec.MediaType, codec.Codec}}, codec.fallbacks...)
+	var body bytes.Buffer
+	var encodeErr, jsonErr error
+	mediaType := ""
+	for _, candidate := range candidates {
+		body.Reset()
+		if err := candidate.codec.Encode(&body, v); err != nil {
+			if encodeErr == nil {
+				encodeErr = err
+			}
+			if candidate.mediaType == "application/json" {
+				jsonErr = err
+			}
+			continue
+		}
+		mediaType = candidate.mediaType
+		break
+	}
+
+	if mediaType == "" {
+		//anything should be encodable as JSON, so that failing is a bug
+		if jsonErr != nil {
+			return jsonErr
+		}
+		return Errorf(
+			http.StatusNotAcceptable,
+			"the response can't be encoded as any accepted media type: %s",
+			encodeErr.Error(),
+		)
+	}
+
+	if res.Header().Get("Content-Type") == "" {
+		res.Header().Set("Content-Type", mediaType)
+	}
+
+	if code != 0 {
+		res.WriteHeader(code)
+	}
+
+	_, err := body.WriteTo(res)
+	return err
+}
+
+// acceptedMediaTypes parses an Accept header into the media types it lists,
+// most preferred first. A range that covers JSON comes before the types it
+// ties with, so clients that will take anything get JSON.
+func acceptedMediaTypes(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{"*/*"}
+	}
+
+	type preference struct {
+		mediaType string
+		quality   float64
+		jsonRange bool
+	}
+
+	preferences := []preference{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			jsonRange := strings.Contains(mediaType, "*") && mediaTypeMatches(mediaType, "application/json")
+			preferences = append(preferences, preference{mediaType, quality, jsonRange})
+		}
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		if preferences[i].quality != preferences[j].quality {
+			return preferences[i].quality > preferences[j].quality
+		}
+		return preferences[i].jsonRange && !preferences[j].jsonRange
+	})
+
+	types := make([]string, len(preferences))
+	for i, p := range preferences {
+		types[i] = p.mediaType
+	}
+	return types
+}
+
+func mediaTypeMatches(accepted, mediaType string) bool {
+	if accepted == "*/*" || accepted == mediaType {
+		return true
+	}
+	if strings.HasSuffix(accepted, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(accepted, "*"))
+	}
+	return false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// formCodec reads and writes url encoded forms, for structs and maps of
+// strings. Struct fields are named by their `form` tag, then their `json`
+// tag, then the field name.
+type formCodec struct{}
+
+func (formCodec) Decode(r io.Reader, v interface{}) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+
+	switch target := val.Addr().Interface().(type) {
+	case *url.Values:
+		*target = values
+		return nil
+	case *map[string][]string:
+		*target = values
+		return nil
+	case *map[string]string:
+		*target = map[string]string{}
+		for key := range values {
+			(*target)[key] = values.Get(key)
+		}
+		return nil
+	}
+
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("can't decode a form into %s", val.Type())
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		name := formFieldName(field)
+		if name == "" || len(values[name]) == 0 || isFileField(field.Type) {
+			continue
+		}
+		if err := setFormField(val.Field(i), values[name]); err != nil {
+			return fmt.Errorf("field '%s': %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (formCodec) Encode(w io.Writer, v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	values := url.Values{}
+	switch source := val.Interface().(type) {
+	case url.Values:
+		values = source
+	case map[string][]string:
+		values = source
+	case map[string]string:
+		for key, value := range source {
+			values.Set(key, value)
+		}
+	default:
+		if val.Kind() != reflect.Struct {
+			return fmt.Errorf("can't encode %s as a form", val.Type())
+		}
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			name := formFieldName(field)
+			if name == "" || isFileField(field.Type) {
+				continue
+			}
+			if err := addFormField(values, name, val.Field(i)); err != nil {
+				return fmt.Errorf("field '%s': %v", name, err)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, values.Encode())
+	return err
+}
+
+// formFieldName is the name of the field in a form, or the empty string if
+// the field isn't part of the form
+func formFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	for _, tagName := range []string{"form", "json"} {
+		name := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func setFormField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Ptr {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	_, isText := field.Addr().Interface().(encoding.TextUnmarshaler)
+	if field.Kind() == reflect.Slice && !isText {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setFormValue(field, values[0])
+}
+
+func setFormValue(field reflect.Value, value string) error {
+	if text, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return text.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported form field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func addFormField(values url.Values, name string, field reflect.Value) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+
+	if text, ok := field.Interface().(encoding.TextMarshaler); ok {
+		encoded, err := text.MarshalText()
+		if err != nil {
+			return err
+		}
+		values.Add(name, string(encoded))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			if err := addFormField(values, name, field.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		values.Add(name, fmt.Sprint(field.Interface()))
+	default:
+		return fmt.Errorf("unsupported form field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -18,29 +18,50 @@ type Documentation struct {
 	Endpoints    []*Endpoint      `json:"endpoints"`
 	Types        map[string]*Type `json:"types,omitempty"`
 	Introduction []string
+	schemas      *schemaCollector
 }
 
 type Endpoint struct {
-	Method       string               `json:"method,omitempty"`
-	Path         string               `json:"path"`
-	Description  string               `json:"description,omitempty"`
-	RequestBody  string               `json:"requestBody,omitempty"`
-	ResponseBody string               `json:"responseBody,omitempty"`
-	Params       map[string]ParamInfo `json:"params,omitempty"`
-	Notes        []string             `json:"notes,omitempty"`
+	Name           string                `json:"name,omitempty"`
+	Method         string                `json:"method,omitempty"`
+	Path           string                `json:"path"`
+	Description    string                `json:"description,omitempty"`
+	RequestBody    string                `json:"requestBody,omitempty"`
+	ResponseBody   string                `json:"responseBody,omitempty"`
+	ResponseStream string                `json:"responseStream,omitempty"`
+	Params         map[string]ParamInfo  `json:"params,omitempty"`
+	PathParams     map[string]ParamInfo  `json:"pathParams,omitempty"`
+	HeaderParams   map[string]ParamInfo  `json:"headerParams,omitempty"`
+	CookieParams   map[string]ParamInfo  `json:"cookieParams,omitempty"`
+	Uploads        map[string]UploadInfo `json:"uploads,omitempty"`
+	Status         int                   `json:"status,omitempty"`
+	Middleware     []string              `json:"middleware,omitempty"`
+	Notes          []string              `json:"notes,omitempty"`
 }
 
 type Type struct {
 	Description string      `json:"description,omitempty"`
 	Example     interface{} `json:"example"`
+	Schema      *Schema     `json:"schema,omitempty"`
 }
 
 type ParamInfo struct {
 	Type        string `json:"type"`
+	Items       string `json:"items,omitempty"`
 	Required    bool   `json:"required"`
+	Default     string `json:"default,omitempty"`
+	Constraint  string `json:"constraint,omitempty"`
 	Description string `json:"description,omitempty"`
 }
 
+// UploadInfo describes a file uploaded in a multipart/form-data request
+type UploadInfo struct {
+	Required bool     `json:"required"`
+	Multiple bool     `json:"multiple"`
+	Accept   []string `json:"accept,omitempty"`
+	MaxSize  int64    `json:"maxSize,omitempty"`
+}
+
 func (sm *ServeMux) Documentation(introduction ...string) *Documentation {
 	for i, line := range introduction {
 		introduction[i] = cleanupText(line)
@@ -48,15 +69,37 @@ func (sm *ServeMux) Documentation(introduction ...string) *Documentation {
 	d := &Documentation{
 		Types:        map[string]*Type{},
 		Introduction: introduction,
+		schemas:      newSchemaCollector("#/types/%s/schema"),
 	}
 	d.collectEndpoints(sm.Paths)
+
+	if muxMiddleware := middlewareNames(sm.middleware); len(muxMiddleware) > 0 {
+		for _, e := range d.Endpoints {
+			e.Middleware = append(append([]string{}, muxMiddleware...), e.Middleware...)
+		}
+	}
+
+	//types only referred to by other types' schemas need entries too
+	for name, typ := range d.schemas.types {
+		d.addType(name, typ, d.schemas.defs[name])
+	}
+
 	return d
 }
 
 func (d *Documentation) collectEndpoints(paths *Paths) {
 	for path, segment := range paths.flatten() {
 		docs := cleanupText(strings.Join(segment.documentation, "\n"))
-		d.collectEndpoint(path, segment.originalHandler, docs)
+		handler, middleware := unwrapRoute(segment.originalHandler)
+		first := len(d.Endpoints)
+		d.collectEndpoint(path, handler, docs)
+		for _, e := range d.Endpoints[first:] {
+			e.Name = routeName(segment.originalHandler)
+			e.addPathVariables(path)
+			if len(middleware) > 0 {
+				e.Middleware = append(middlewareNames(middleware), e.Middleware...)
+			}
+		}
 	}
 }
 
@@ -85,10 +128,14 @@ func (d *Documentation) collectEndpoint(path string, handler interface{}, docs s
 func (d *Documentation) collectMethodEndpoints(path string, handlers *ByMethod, docs string) {
 	addHandler := func(method string, handler interface{}) {
 		if handler != nil {
+			handler, middleware := unwrapRoute(handler)
 			e := d.handlerFunctionToEndpoint(handler)
 			e.Method = method
 			e.Path = path
 			e.Description = docs
+			if len(middleware) > 0 {
+				e.Middleware = middlewareNames(middleware)
+			}
 			d.Endpoints = append(d.Endpoints, e)
 		}
 	}
@@ -112,38 +159,31 @@ func (d *Documentation) handlerFunctionToEndpoint(handler interface{}) *Endpoint
 		panic(fmt.Errorf("error generating documentation: %v", err))
 	}
 
-	e := &Endpoint{}
+	e := &Endpoint{
+		Status: successStatus(info.Outputs),
+	}
 
 	for _, input := range info.Inputs {
 		switch t := input.ConversionType; t {
 		case generate.ConvertBody:
 			e.RequestBody = d.mkType(input.Type)
+			e.addUploadFields(input.Type)
+		case generate.ConvertFile:
+			e.addUpload("*", input.Type, nil)
+		case generate.ConvertContext, generate.ConvertRequest, generate.ConvertResponseWriter:
+			//supplied by the request, nothing to document
 		case generate.ConvertCustom:
 			val := reflect.Zero(input.Type).Interface()
 			if doc, ok := val.(documenter); ok {
 				e.Notes = append(e.Notes, cleanupText(doc.Documentation()))
 			}
-		case generate.ConvertIntQueryParam, generate.ConvertStringQueryParam:
-			p := ParamInfo{
-				Required: input.Type.Kind() != reflect.Ptr,
-			}
-
-			val := reflect.Zero(input.Type).Interface()
-			if doc, ok := val.(documenter); ok {
-				p.Description = cleanupText(doc.Documentation())
-			}
-
-			if t == generate.ConvertIntQueryParam {
-				p.Type = "string"
-			} else {
-				p.Type = "integer"
-			}
-
-			if e.Params == nil {
-				e.Params = map[string]ParamInfo{}
+		case generate.ConvertQueryParam, generate.ConvertPathParam,
+			generate.ConvertHeaderParam, generate.ConvertCookieParam:
+			e.addParam(input)
+		case generate.ConvertParams:
+			for _, field := range input.Fields {
+				e.addParam(field)
 			}
-
-			e.Params[input.Name] = p
 		default:
 			log.Fatalf("unexpected conversion type %s", t)
 		}
@@ -153,6 +193,16 @@ func (d *Documentation) handlerFunctionToEndpoint(handler interface{}) *Endpoint
 		switch t := output.ConversionType; t {
 		case generate.ConvertBody:
 			e.ResponseBody = d.mkType(output.Type)
+		case generate.ConvertReader:
+			e.ResponseStream = "application/octet-stream"
+		case generate.ConvertChannel:
+			e.ResponseBody = d.mkType(output.Type.Elem())
+			e.ResponseStream = "application/x-ndjson"
+		case generate.ConvertEvents:
+			if output.ToEvent {
+				e.ResponseBody = d.mkType(output.Type.Elem())
+			}
+			e.ResponseStream = "text/event-stream"
 		case generate.ConvertCustom:
 			val := reflect.Zero(output.Type).Interface()
 			if doc, ok := val.(documenter); ok {
@@ -168,26 +218,155 @@ func (d *Documentation) handlerFunctionToEndpoint(handler interface{}) *Endpoint
 	return e
 }
 
-func (d *Documentation) mkType(typ reflect.Type) string {
-	name := typeName(typ)
+// successStatus is the status of a successful response, as declared by the
+// return values whose ResponseCode doesn't depend on their value
+func successStatus(outputs []*generate.Converter) int {
+	values := []interface{}{}
+	for _, output := range outputs {
+		if output.ConversionType == generate.ConvertError {
+			continue
+		}
+		if output.Type.Kind() == reflect.Ptr {
+			values = append(values, reflect.New(output.Type.Elem()).Interface())
+		} else {
+			values = append(values, reflect.Zero(output.Type).Interface())
+		}
+	}
 
-	log.Printf("name: %#v", name)
+	if code := ResponseCodeOf(values...); code != 0 {
+		return code
+	}
+	return http.StatusOK
+}
 
-	if _, ok := d.Types[name]; !ok {
-		example := deepZero(typ).Interface()
-		description := ""
-		if documenter, ok := example.(documenter); ok {
-			description = cleanupText(documenter.Documentation())
+// addUploadFields adds the File fields of a request body struct
+func (e *Endpoint) addUploadFields(typ reflect.Type) {
+	if !hasFileFields(typ) {
+		return
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if name := formFieldName(field); name != "" && isFileField(field.Type) {
+			e.addUpload(name, field.Type, acceptedTypes(field))
 		}
-		d.Types[name] = &Type{
-			Example:     example,
-			Description: description,
+	}
+}
+
+func (e *Endpoint) addUpload(name string, typ reflect.Type, accept []string) {
+	if e.Uploads == nil {
+		e.Uploads = map[string]UploadInfo{}
+	}
+	e.Uploads[name] = UploadInfo{
+		Required: typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Slice,
+		Multiple: typ.Kind() == reflect.Slice,
+		Accept:   accept,
+		MaxSize:  MaxUploadSize,
+	}
+}
+
+func (e *Endpoint) addParam(param *generate.Converter) {
+	p := ParamInfo{
+		Required: !param.IsPointer && param.Default == "",
+		Type:     paramTypeName(param.ParamKind),
+		Default:  param.Default,
+	}
+
+	if param.IsSlice {
+		p.Items = p.Type
+		p.Type = "array"
+	}
+
+	val := reflect.Zero(param.Type).Interface()
+	if doc, ok := val.(documenter); ok {
+		p.Description = cleanupText(doc.Documentation())
+	}
+
+	var params *map[string]ParamInfo
+	switch param.ConversionType {
+	case generate.ConvertPathParam:
+		params = &e.PathParams
+	case generate.ConvertHeaderParam:
+		params = &e.HeaderParams
+	case generate.ConvertCookieParam:
+		params = &e.CookieParams
+	default:
+		params = &e.Params
+	}
+
+	if *params == nil {
+		*params = map[string]ParamInfo{}
+	}
+
+	(*params)[param.Name] = p
+}
+
+// addPathVariables documents the variables in the route which the handler
+// doesn't take as arguments, but can still get with PathParam, and the
+// constraints on all of them
+func (e *Endpoint) addPathVariables(path string) {
+	for _, variable := range routeVariables(path) {
+		p, exists := e.PathParams[variable.name]
+		if !exists {
+			p = ParamInfo{Type: variable.constraint.schema().Type, Required: true}
+			if variable.isWildcard {
+				p.Description = wildcardDescription
+			}
 		}
+		if variable.constraint != nil {
+			p.Constraint = variable.constraint.source
+		}
+
+		if e.PathParams == nil {
+			e.PathParams = map[string]ParamInfo{}
+		}
+		e.PathParams[variable.name] = p
 	}
+}
+
+func (d *Documentation) mkType(typ reflect.Type) string {
+	name := typeName(typ)
+
+	schema := d.schemas.schema(typ)
+	if def, ok := d.schemas.defs[name]; ok && schema.Ref != "" {
+		schema = def
+	}
+	d.addType(name, typ, schema)
 
 	return name
 }
 
+func (d *Documentation) addType(name string, typ reflect.Type, schema *Schema) {
+	if _, ok := d.Types[name]; ok {
+		return
+	}
+
+	example := deepZero(typ).Interface()
+	description := ""
+	if documenter, ok := example.(documenter); ok {
+		description = cleanupText(documenter.Documentation())
+	}
+	d.Types[name] = &Type{
+		Example:     example,
+		Description: description,
+		Schema:      schema,
+	}
+}
+
+func paramTypeName(kind generate.ParamKind) string {
+	switch kind {
+	case generate.ParamInt, generate.ParamUint:
+		return "integer"
+	case generate.ParamFloat:
+		return "number"
+	case generate.ParamBool:
+		return "boolean"
+	}
+	return "string"
+}
+
 func typeName(typ reflect.Type) string {
 	name := fmt.Sprintf("%v", typ)
 	parts := strings.Split(name, ".")
@@ -196,6 +375,18 @@ func typeName(typ reflect.Type) string {
 }
 
 func deepZero(typ reflect.Type) reflect.Value {
+	return deepZeroSeen(typ, map[reflect.Type]bool{})
+}
+
+// deepZeroSeen stops filling in examples once it finds a type it's already
+// inside of, so recursive types don't recurse forever
+func deepZeroSeen(typ reflect.Type, seen map[reflect.Type]bool) reflect.Value {
+	if seen[typ] {
+		return reflect.New(typ).Elem()
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
 	needsExample := func(v reflect.Value) bool {
 		isPtrOrSliceOrMap := v.Kind() == reflect.Ptr || v.Kind() == reflect.Slice || v.Kind() == reflect.Map
 		canSet := isPtrOrSliceOrMap && v.CanSet()
@@ -203,7 +394,7 @@ func deepZero(typ reflect.Type) reflect.Value {
 	}
 
 	if typ.Kind() == reflect.Ptr {
-		val := deepZero(typ.Elem())
+		val := deepZeroSeen(typ.Elem(), seen)
 		if val.CanAddr() {
 			return val.Addr()
 		} else {
@@ -215,15 +406,15 @@ func deepZero(typ reflect.Type) reflect.Value {
 		slice := reflect.MakeSlice(typ, 1, 1)
 		val := slice.Index(0)
 		if needsExample(val) {
-			val.Set(deepZero(typ.Elem()))
+			val.Set(deepZeroSeen(typ.Elem(), seen))
 		}
 		return slice
 	}
 
 	if typ.Kind() == reflect.Map {
 		m := reflect.MakeMap(typ)
-		key := deepZero(typ.Key())
-		val := deepZero(typ.Elem())
+		key := deepZeroSeen(typ.Key(), seen)
+		val := deepZeroSeen(typ.Elem(), seen)
 		m.SetMapIndex(key, val)
 		return m
 	}
@@ -237,7 +428,7 @@ func deepZero(typ reflect.Type) reflect.Value {
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		if needsExample(field) {
-			val.Field(i).Set(deepZero(val.Field(i).Type()))
+			val.Field(i).Set(deepZeroSeen(val.Field(i).Type(), seen))
 		}
 	}
 	return val
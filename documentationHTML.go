@@ -70,22 +70,71 @@ var page = template.Must(template.New("docs page").Parse(`
 					{{.Description}}
 				</p>
 			{{end}}
+			{{if and .Status (ne .Status 200)}}
+				<p>
+					Responds with status {{.Status}} when successful
+				</p>
+			{{end}}
+			{{if .Middleware}}
+				<p>
+					Middleware: {{range $i, $name := .Middleware}}{{if $i}}, {{end}}<code>{{$name}}</code>{{end}}
+				</p>
+			{{end}}
 			{{range .Notes}}
 				<p>
 					{{.}}
 				</p>
 			{{end}}
+			{{if .PathParams}}
+			  <div>
+					<h3>Path Params</h3>
+					{{range $key, $val := .PathParams}}
+					  <div>
+							<span class="paramName">{{$key}}</span> {{template "param" $val}}
+						</div>
+					{{end}}
+				</div>
+			{{end}}
 			{{if .Params}}
 			  <div>
 					<h3>Params</h3>
 					{{range $key, $val := .Params}}
+					  <div>
+							<span class="paramName">{{$key}}</span> {{template "param" $val}}
+						</div>
+					{{end}}
+				</div>
+			{{end}}
+			{{if .HeaderParams}}
+			  <div>
+					<h3>Header Params</h3>
+					{{range $key, $val := .HeaderParams}}
+					  <div>
+							<span class="paramName">{{$key}}</span> {{template "param" $val}}
+						</div>
+					{{end}}
+				</div>
+			{{end}}
+			{{if .CookieParams}}
+			  <div>
+					<h3>Cookie Params</h3>
+					{{range $key, $val := .CookieParams}}
+					  <div>
+							<span class="paramName">{{$key}}</span> {{template "param" $val}}
+						</div>
+					{{end}}
+				</div>
+			{{end}}
+			{{if .Uploads}}
+			  <div>
+					<h3>File Uploads</h3>
+					{{range $key, $val := .Uploads}}
 					  <div>
 							<span class="paramName">{{$key}}</span> (
-							{{- if $val.Required -}}
-								Required
-							{{- else -}}
-								Optional
-							{{- end}} {{$val.Type}}): {{$val.Description}}
+							{{- if $val.Required}}Required{{else}}Optional{{end}}
+							{{- if $val.Multiple}}, multiple files{{end}}
+							{{- if $val.Accept}}, accepts {{range $i, $type := $val.Accept}}{{if $i}}, {{end}}{{$type}}{{end}}{{end}}
+							{{- if $val.MaxSize}}, up to {{$val.MaxSize}} bytes{{end}})
 						</div>
 					{{end}}
 				</div>
@@ -106,9 +155,23 @@ var page = template.Must(template.New("docs page").Parse(`
 					</div>
 				</div>
 			{{end}}
+			{{if .ResponseStream}}
+				<div>
+					<h3>Streamed Response</h3>
+					<div>
+						{{.ResponseStream}}
+					</div>
+				</div>
+			{{end}}
 		</div>
 	{{end}}
 </body>
+{{define "param" -}}
+	({{if .Required}}Required{{else}}Optional{{end}} {{.Type}}
+	{{- if .Items}} of {{.Items}}{{end}}
+	{{- if .Default}}, default {{.Default}}{{end}}
+	{{- if .Constraint}}, matching {{.Constraint}}{{end}}): {{.Description}}
+{{- end}}
 `))
 
 type docOrder []*Endpoint
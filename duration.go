@@ -0,0 +1,27 @@
+package plumbus
+
+import (
+	"time"
+)
+
+// Duration is a time.Duration which parameters can be defined from. A type
+// defined from time.Duration itself is indistinguishable from an int64, but
+// one like `type timeoutQueryParam plumbus.Duration` is parsed with
+// time.ParseDuration, so "?timeout=5s" works. The same goes for parameter
+// struct fields of this type.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
@@ -1,6 +1,12 @@
 package plumbus
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
 
 type wrappedError struct {
 	error
@@ -11,6 +17,10 @@ func (we *wrappedError) ResponseCode() int {
 	return we.code
 }
 
+func (we *wrappedError) Unwrap() error {
+	return we.error
+}
+
 func WrapError(code int, err error) error {
 	if err == nil {
 		return nil
@@ -47,3 +57,83 @@ func Errorf(code int, msg string, args ...interface{}) error {
 		msg:  fmt.Sprintf(msg, args...),
 	}
 }
+
+// ParamErrors collects the problems with each of the parameters bound to a
+// struct, so they can all be reported at once
+type ParamErrors []error
+
+func (pe ParamErrors) Error() string {
+	msgs := make([]string, len(pe))
+	for i, err := range pe {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (pe ParamErrors) ResponseCode() int {
+	return http.StatusBadRequest
+}
+
+// ProblemExtensions lists each of the problems separately in problem details
+func (pe ParamErrors) ProblemExtensions() map[string]interface{} {
+	msgs := make([]string, len(pe))
+	for i, err := range pe {
+		msgs[i] = err.Error()
+	}
+	return map[string]interface{}{"errors": msgs}
+}
+
+type errorCode struct {
+	target error
+	code   int
+}
+
+var (
+	errorCodesLock sync.RWMutex
+	errorCodes     []errorCode
+)
+
+// RegisterErrorCode sends errors matching target, as reported by errors.Is,
+// with the response code given, e.g. sql.ErrNoRows as a 404. Registering the
+// same target again replaces its code.
+func RegisterErrorCode(target error, code int) {
+	errorCodesLock.Lock()
+	defer errorCodesLock.Unlock()
+
+	for i, registered := range errorCodes {
+		if registered.target == target {
+			errorCodes[i].code = code
+			return
+		}
+	}
+	errorCodes = append(errorCodes, errorCode{target, code})
+}
+
+// AsHTTPError finds the HTTPError in err's chain, or failing that, the code
+// registered for an error in it. The HTTPError returned has the message of
+// err itself, so wrapping an error keeps its code and adds to its message.
+func AsHTTPError(err error) (HTTPError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	if httperr, ok := err.(HTTPError); ok {
+		return httperr, true
+	}
+
+	var httperr HTTPError
+	if errors.As(err, &httperr) {
+		return &wrappedError{error: err, code: httperr.ResponseCode()}, true
+	}
+
+	errorCodesLock.RLock()
+	defer errorCodesLock.RUnlock()
+
+	for _, registered := range errorCodes {
+		if errors.Is(err, registered.target) {
+			return &wrappedError{error: err, code: registered.code}, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,146 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Event is a single server-sent event. A handler returning a receive channel
+// of Events, or of a type with a `ToEvent() plumbus.Event` method, is served
+// as a text/event-stream. Data is sent as is if it's a string or []byte, and
+// as JSON otherwise.
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// EventHeartbeat is how often a comment is sent on an event stream, to keep
+// proxies from closing idle connections. Zero turns heartbeats off.
+var EventHeartbeat = 15 * time.Second
+
+// LastEventID is the id of the last event a reconnecting client received,
+// empty if it's connecting for the first time
+type LastEventID string
+
+func (id *LastEventID) FromRequest(req *http.Request) error {
+	*id = LastEventID(req.Header.Get("Last-Event-ID"))
+	return nil
+}
+
+// StartEventStream sends the headers of an event stream. A code other than
+// 0 is sent as the response's status.
+func StartEventStream(res http.ResponseWriter, code int) {
+	res.Header().Set("Cache-Control", "no-cache")
+	StartStream(res, code, "text/event-stream")
+}
+
+// EventHeartbeatTicker delivers a time whenever a heartbeat is due, and never
+// if heartbeats are turned off. stop must be called once the stream ends.
+func EventHeartbeatTicker() (ticks <-chan time.Time, stop func()) {
+	if EventHeartbeat <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(EventHeartbeat)
+	return ticker.C, ticker.Stop
+}
+
+// SendEvent writes a single event and flushes it to the client. Once it
+// returns an error the stream should be stopped.
+func SendEvent(res http.ResponseWriter, req *http.Request, event Event) error {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", eventField(event.ID))
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", eventField(event.Event))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", event.Retry/time.Millisecond)
+	}
+
+	var data string
+	switch val := event.Data.(type) {
+	case string:
+		data = val
+	case []byte:
+		data = string(val)
+	default:
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			logStreamError(req, err)
+			return err
+		}
+		data = string(encoded)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	buf.WriteString("\n")
+
+	return writeEventStream(res, req, buf.Bytes())
+}
+
+// SendEventHeartbeat writes a comment, which clients ignore
+func SendEventHeartbeat(res http.ResponseWriter, req *http.Request) error {
+	return writeEventStream(res, req, []byte(": heartbeat\n\n"))
+}
+
+// StreamEvents sends each value received from ch, which must be a channel of
+// Events or of a type with a ToEvent method, until ch is closed or the client
+// goes away
+func StreamEvents(res http.ResponseWriter, req *http.Request, code int, ch interface{}) {
+	StartEventStream(res, code)
+
+	ticks, stop := EventHeartbeatTicker()
+	defer stop()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(req.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ticks)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)},
+	}
+	for {
+		chosen, value, ok := reflect.Select(cases)
+		var err error
+		switch {
+		case chosen == 0:
+			return
+		case chosen == 1:
+			err = SendEventHeartbeat(res, req)
+		case !ok:
+			return
+		default:
+			event, isEvent := value.Interface().(Event)
+			if !isEvent {
+				event = value.Interface().(interface{ ToEvent() Event }).ToEvent()
+			}
+			err = SendEvent(res, req, event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeEventStream(res http.ResponseWriter, req *http.Request, data []byte) error {
+	if _, err := res.Write(data); err != nil {
+		logStreamError(req, err)
+		return err
+	}
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// eventField keeps a newline from ending an event's id or name early
+func eventField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -7,15 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
-	"fmt"
+	"strconv"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
+var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -36,7 +40,19 @@ func init(){
 			
 		))
 
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+			
+				responseCodec, err := plumbus.NegotiateCodec(req)
+				if err != nil {
+					plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+					return
+				}
+			
 			
 			
 
@@ -54,16 +70,28 @@ func init(){
 
 			
 				
+			
+
+			code := plumbus.ResponseCodeOf(
+				
 					
-						{
-							if err := json.NewEncoder(res).Encode(result0); err != nil {
-								plumbus.HandleResponseError(res, req, err)
-								return
-							}
-						}
+						result0,
 					
 				
+			)
+
+			
+				
+				
+				
+					if err := plumbus.EncodeBody(res, code, responseCodec, result0); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+						return
+					}
+				
 			
 		})
 	})
 }
+
+
@@ -0,0 +1,152 @@
+
+package handlers
+
+//code generated by 'go generate', do not edit
+
+import (
+	"github.com/jargv/plumbus"
+	"net/http"
+	"reflect"
+	"strconv"
+	"log"
+	"context"
+	"io"
+	"time"
+)
+
+// avoid unused import errors
+var _ log.Logger
+var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
+
+func init(){
+	var dummy func(
+		
+			userIdPathParam,
+		
+			*User,
+		
+	)(
+		
+	)
+
+	typ := reflect.TypeOf(dummy)
+	plumbus.RegisterAdaptor(typ, func(handler interface{}) http.HandlerFunc {
+		callback := handler.(func(
+			
+				userIdPathParam,
+			
+				*User,
+			
+		)(
+			
+		))
+
+		
+			
+		
+			
+				if err := plumbus.CheckValidateTags(reflect.TypeOf((**User)(nil)).Elem()); err != nil {
+					panic(err)
+				}
+			
+		
+
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+				defer plumbus.CleanupMultipart(req)
+			
+			
+			
+			
+				var arg0 userIdPathParam
+					
+	{
+		
+			var values []string
+			if value, sent := plumbus.PathParam(req, "userId"); sent {
+				values = []string{value}
+			}
+		
+		
+		
+		var paramErr error
+		
+			if len(values) == 0 {
+				paramErr = plumbus.Errorf(
+					http.StatusBadRequest,
+					"missing required path parameter 'userId'",
+				)
+			} else {
+		
+			
+				value := values[0]
+				
+	
+		parsedInt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"path param 'userId' expected to be integer value",
+			)
+		}
+		parsed := userIdPathParam(parsedInt)
+	
+
+				arg0 = parsed
+			
+		}
+		if paramErr != nil {
+			
+				plumbus.HandleError(res, req, paramErr, plumbus.SourceParam)
+				return
+			
+		}
+	}
+
+				
+			
+				var arg1 *User
+					if err := plumbus.DecodeBody(req, &arg1); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceDecode)
+						return
+					}
+					if err := plumbus.Validate(arg1); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceValidation)
+						return
+					}
+				
+			
+
+			
+			
+
+			callback(
+				
+					arg0,
+				
+					arg1,
+				
+			)
+
+			
+			
+
+			
+
+			code := plumbus.ResponseCodeOf(
+				
+			)
+
+			
+				plumbus.WriteResponseCode(res, code)
+			
+		})
+	})
+}
+
+
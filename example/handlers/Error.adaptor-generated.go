@@ -7,15 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
-	"fmt"
+	"strconv"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
+var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -36,7 +40,13 @@ func init(){
 			
 		))
 
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+			
 			
 			
 
@@ -52,7 +62,7 @@ func init(){
 			
 			
 				if result0 != nil {
-					plumbus.HandleResponseError(res, req, result0.(error))
+					plumbus.HandleError(res, req, result0.(error), plumbus.SourceHandler)
 					return
 				}
 			
@@ -60,6 +70,18 @@ func init(){
 			
 				
 			
+
+			code := plumbus.ResponseCodeOf(
+				
+					
+				
+			)
+
+			
+				plumbus.WriteResponseCode(res, code)
+			
 		})
 	})
 }
+
+
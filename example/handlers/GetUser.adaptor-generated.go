@@ -0,0 +1,152 @@
+
+package handlers
+
+//code generated by 'go generate', do not edit
+
+import (
+	"github.com/jargv/plumbus"
+	"net/http"
+	"reflect"
+	"strconv"
+	"log"
+	"context"
+	"io"
+	"time"
+)
+
+// avoid unused import errors
+var _ log.Logger
+var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
+
+func init(){
+	var dummy func(
+		
+			userIdPathParam,
+		
+	)(
+		
+			*User,
+		
+	)
+
+	typ := reflect.TypeOf(dummy)
+	plumbus.RegisterAdaptor(typ, func(handler interface{}) http.HandlerFunc {
+		callback := handler.(func(
+			
+				userIdPathParam,
+			
+		)(
+			
+				*User,
+			
+		))
+
+		
+			
+		
+
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+			
+				responseCodec, err := plumbus.NegotiateCodec(req)
+				if err != nil {
+					plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+					return
+				}
+			
+			
+			
+				var arg0 userIdPathParam
+					
+	{
+		
+			var values []string
+			if value, sent := plumbus.PathParam(req, "userId"); sent {
+				values = []string{value}
+			}
+		
+		
+		
+		var paramErr error
+		
+			if len(values) == 0 {
+				paramErr = plumbus.Errorf(
+					http.StatusBadRequest,
+					"missing required path parameter 'userId'",
+				)
+			} else {
+		
+			
+				value := values[0]
+				
+	
+		parsedInt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"path param 'userId' expected to be integer value",
+			)
+		}
+		parsed := userIdPathParam(parsedInt)
+	
+
+				arg0 = parsed
+			
+		}
+		if paramErr != nil {
+			
+				plumbus.HandleError(res, req, paramErr, plumbus.SourceParam)
+				return
+			
+		}
+	}
+
+				
+			
+
+			
+			
+				result0  := 
+			
+
+			callback(
+				
+					arg0,
+				
+			)
+
+			
+			
+
+			
+				
+			
+
+			code := plumbus.ResponseCodeOf(
+				
+					
+						result0,
+					
+				
+			)
+
+			
+				
+				
+				
+					if err := plumbus.EncodeBody(res, code, responseCodec, result0); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+						return
+					}
+				
+			
+		})
+	})
+}
+
+
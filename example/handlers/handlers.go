@@ -53,19 +53,21 @@ func (example *User) Documentation() string {
 	`
 }
 
-type userIdQueryParam int
+type userIdPathParam int
 
-func (userIdQueryParam) Documentation() string {
+func (userIdPathParam) Documentation() string {
 	return `
 	  the id of the user
 	`
 }
 
-func EditUser(id userIdQueryParam, user *User) {
+//go:generate plumbus EditUser
+func EditUser(id userIdPathParam, user *User) {
 
 }
 
-func GetUser(id userIdQueryParam) *User {
+//go:generate plumbus GetUser
+func GetUser(id userIdPathParam) *User {
 	return nil
 }
 
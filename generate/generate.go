@@ -2,10 +2,10 @@ package generate
 
 import (
 	"fmt"
-	"html/template"
 	"os"
 	"reflect"
 	"strings"
+	"text/template"
 )
 
 func Adaptor(handler interface{}, filepath, pkg string) error {
@@ -21,6 +21,9 @@ func Adaptor(handler interface{}, filepath, pkg string) error {
 
 	tmpl, err := template.New("adaptor").
 		Funcs(template.FuncMap{
+			"isStruct": func(typ reflect.Type) bool {
+				return typ.Kind() == reflect.Struct
+			},
 			"typename": func(arg interface{}) string {
 				typename := fmt.Sprintf("%s", arg)
 				return strings.Replace(typename, pkg+".", "", 1)
@@ -38,15 +41,76 @@ func Adaptor(handler interface{}, filepath, pkg string) error {
 			"ConvertCustom": func() ConversionType {
 				return ConvertCustom
 			},
-			"ConvertStringQueryParam": func() ConversionType {
-				return ConvertStringQueryParam
+			"ConvertQueryParam": func() ConversionType {
+				return ConvertQueryParam
+			},
+			"ConvertPathParam": func() ConversionType {
+				return ConvertPathParam
+			},
+			"ConvertHeaderParam": func() ConversionType {
+				return ConvertHeaderParam
+			},
+			"ConvertCookieParam": func() ConversionType {
+				return ConvertCookieParam
+			},
+			"ConvertParams": func() ConversionType {
+				return ConvertParams
+			},
+			"ConvertReader": func() ConversionType {
+				return ConvertReader
+			},
+			"ConvertChannel": func() ConversionType {
+				return ConvertChannel
+			},
+			"ConvertEvents": func() ConversionType {
+				return ConvertEvents
+			},
+			"ConvertFile": func() ConversionType {
+				return ConvertFile
+			},
+			"ConvertContext": func() ConversionType {
+				return ConvertContext
+			},
+			"ConvertRequest": func() ConversionType {
+				return ConvertRequest
 			},
-			"ConvertIntQueryParam": func() ConversionType {
-				return ConvertIntQueryParam
+			"ConvertResponseWriter": func() ConversionType {
+				return ConvertResponseWriter
+			},
+			"ParamString": func() ParamKind {
+				return ParamString
+			},
+			"ParamInt": func() ParamKind {
+				return ParamInt
+			},
+			"ParamText": func() ParamKind {
+				return ParamText
+			},
+			"ParamUint": func() ParamKind {
+				return ParamUint
+			},
+			"ParamFloat": func() ParamKind {
+				return ParamFloat
+			},
+			"ParamBool": func() ParamKind {
+				return ParamBool
+			},
+			"ParamTime": func() ParamKind {
+				return ParamTime
+			},
+			"ParamDuration": func() ParamKind {
+				return ParamDuration
+			},
+			"dict": func(pairs ...interface{}) map[string]interface{} {
+				m := map[string]interface{}{}
+				for i := 0; i+1 < len(pairs); i += 2 {
+					m[pairs[i].(string)] = pairs[i+1]
+				}
+				return m
 			},
 		}).
 		Option("missingkey=error").
-		Parse(adaptorTemplate)
+		Parse(adaptorTemplate + paramTemplate)
 
 	if err != nil {
 		return err
@@ -68,17 +132,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
 	"strconv"
-	"fmt"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
 var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -103,17 +169,39 @@ func init(){
 			{{end}}
 		))
 
+		{{range $_, $input := .info.Inputs}}
+			{{if or (eq $input.ConversionType ConvertBody) (eq $input.ConversionType ConvertParams)}}
+				if err := plumbus.CheckValidateTags(reflect.TypeOf((*{{typename $input.Type}})(nil)).Elem()); err != nil {
+					panic(err)
+				}
+			{{end}}
+		{{end}}
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
 			{{if .info.UsesQueryParams}}
 				queryParams := req.URL.Query()
 			{{end}}
+			{{if .info.ReadsBody}}
+				defer plumbus.CleanupMultipart(req)
+			{{end}}
+			{{if .info.EncodesBody}}
+				responseCodec, err := plumbus.NegotiateCodec(req)
+				if err != nil {
+					plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+					return
+				}
+			{{end}}
 			{{$info := .info}}
 			{{range $i, $arg := $info.Inputs}}
 				var arg{{$i}} {{typename $arg.Type -}}
 				{{if eq $arg.ConversionType ConvertBody}}
-					if err := json.NewDecoder(req.Body).Decode(&arg{{$i}}); err != nil {
-						msg := fmt.Sprintf("{\"error\": \"decoding json: %s\"}", err.Error())
-						http.Error(res, msg, http.StatusBadRequest)
+					if err := plumbus.DecodeBody(req, &arg{{$i}}); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceDecode)
+						return
+					}
+					if err := plumbus.Validate(arg{{$i}}); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceValidation)
 						return
 					}
 				{{else if eq $arg.ConversionType ConvertCustom}}
@@ -121,73 +209,43 @@ func init(){
 						arg{{$i}} = new({{typenameElem $arg.Type}})
 					{{end}}
 					if err := arg{{$i}}.FromRequest(req); err != nil {
-						plumbus.HandleResponseError(res, req, err)
+						plumbus.HandleError(res, req, err, plumbus.SourceParam)
 						return
 					}
-				{{else if eq $arg.ConversionType ConvertStringQueryParam}}
-				  {{if $arg.IsPointer}}
-						if l, sent := queryParams["{{$arg.Name}}"]; sent && len(l) > 0{
-							arg{{$i}} = new({{typenameElem $arg.Type}})
-							*arg{{$i}} = ({{typenameElem $arg.Type}})(l[0])
+				{{else if eq $arg.ConversionType ConvertFile}}
+					if err := plumbus.BindFiles(req, &arg{{$i}}); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceDecode)
+						return
+					}
+				{{else if eq $arg.ConversionType ConvertContext}}
+					arg{{$i}} = req.Context()
+				{{else if eq $arg.ConversionType ConvertRequest}}
+					arg{{$i}} = req
+				{{else if eq $arg.ConversionType ConvertResponseWriter}}
+					arg{{$i}} = res
+				{{else if $arg.ConversionType.IsParam}}
+					{{template "param" (dict "arg" $arg "target" (printf "arg%d" $i) "errors" "")}}
+				{{else if eq $arg.ConversionType ConvertParams}}
+					{{if $arg.IsPointer}}
+						arg{{$i}} = new({{typename $arg.ElemType}})
+					{{end}}
+					{
+						var paramErrs plumbus.ParamErrors
+						{{range $_, $field := $arg.Fields}}
+							{{template "param" (dict
+								"arg" $field
+								"target" (printf "arg%d.%s" $i $field.FieldName)
+								"errors" "paramErrs"
+							)}}
+						{{end}}
+						if len(paramErrs) > 0 {
+							plumbus.HandleError(res, req, paramErrs, plumbus.SourceParam)
+							return
 						}
-					{{else}}
-						if l, sent := queryParams["{{$arg.Name}}"]; sent && len(l) > 0{
-							arg{{$i}} = {{typename $arg.Type}}(l[0])
-						} else {
-							plumbus.HandleResponseError(
-								res, req,
-								plumbus.Errorf(
-									http.StatusBadRequest,
-									"missing required query parameter '{{$arg.Name}}'",
-								),
-							)
+						if err := plumbus.Validate(arg{{$i}}); err != nil {
+							plumbus.HandleError(res, req, err, plumbus.SourceValidation)
 							return
 						}
-					{{end}}
-				{{else if eq $arg.ConversionType ConvertIntQueryParam}}
-				  {
-						{{if $arg.IsPointer}}
-							if l, sent := queryParams["{{$arg.Name}}"]; sent && len(l) > 0 {
-								queryInt, err := strconv.Atoi(l[0])
-								if err != nil {
-									plumbus.HandleResponseError(
-										res, req,
-										plumbus.Errorf(
-											http.StatusBadRequest,
-											"query param '{{$arg.Name}}' expected to be integer value",
-										),
-									)
-									return
-								}
-								arg{{$i}} = new({{typenameElem $arg.Type}})
-								*arg{{$i}} = {{typenameElem $arg.Type}}(queryInt)
-							}
-						{{else}}
-							l, sent := queryParams["{{$arg.Name}}"]
-							if !sent || len(l) == 0 {
-								plumbus.HandleResponseError(
-									res, req,
-									plumbus.Errorf(
-										http.StatusBadRequest,
-										"missing required query parameter '{{$arg.Name}}'",
-									),
-								)
-								return
-							}
-							queryInt, err := strconv.Atoi(l[0])
-							if err != nil {
-								plumbus.HandleResponseError(
-									res, req,
-									plumbus.Errorf(
-										http.StatusBadRequest,
-										"query param '{{$arg.Name}}' expected to be integer value",
-									),
-								)
-								return
-							}
-
-							arg{{$i}} = {{typename $arg.Type}}(queryInt)
-						{{end}}
 					}
 				{{end}}
 			{{end}}
@@ -206,29 +264,221 @@ func init(){
 			{{$lastIsError := .info.LastIsError}}
 			{{if $lastIsError}}
 				if result{{$lastOutput}} != nil {
-					plumbus.HandleResponseError(res, req, result{{$lastOutput}}.(error))
+					plumbus.HandleError(res, req, result{{$lastOutput}}.(error), plumbus.SourceHandler)
 					return
 				}
 			{{end}}
 
-			{{range $i, $_ := .info.Outputs}}
-				{{if or (ne $i $lastOutput) (not $lastIsError)}}
-					{{if eq $i $info.ResponseBodyIndex}}
-						{
-							if err := json.NewEncoder(res).Encode(result{{$i}}); err != nil {
-								plumbus.HandleResponseError(res, req, err)
+			{{range $i, $output := .info.Outputs}}
+				{{if eq $output.ConversionType ConvertCustom}}
+					if err := result{{$i}}.ToResponse(res); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+						return
+					}
+				{{end}}
+			{{end}}
+
+			code := plumbus.ResponseCodeOf(
+				{{range $i, $output := .info.Outputs}}
+					{{if ne $output.ConversionType ConvertError}}
+						result{{$i}},
+					{{end}}
+				{{end}}
+			)
+
+			{{if eq $info.ResponseBodyIndex -1}}
+				plumbus.WriteResponseCode(res, code)
+			{{else}}
+				{{$body := index $info.Outputs $info.ResponseBodyIndex}}
+				{{$result := printf "result%d" $info.ResponseBodyIndex}}
+				{{if eq $body.ConversionType ConvertReader}}
+					plumbus.StreamReader(res, req, code, {{$result}})
+				{{else if eq $body.ConversionType ConvertChannel}}
+					plumbus.StartStream(res, code, "application/x-ndjson")
+					for {
+						select {
+						case <-req.Context().Done():
+							return
+						case value, ok := <-{{$result}}:
+							if !ok {
+								return
+							}
+							if err := plumbus.StreamValue(res, req, value); err != nil {
 								return
 							}
 						}
-					{{else}}
-						if err := result{{$i}}.ToResponse(res); err != nil {
-							plumbus.HandleResponseError(res, req, err)
+					}
+				{{else if eq $body.ConversionType ConvertEvents}}
+					plumbus.StartEventStream(res, code)
+					ticks, stop := plumbus.EventHeartbeatTicker()
+					defer stop()
+					for {
+						var err error
+						select {
+						case <-req.Context().Done():
 							return
+						case <-ticks:
+							err = plumbus.SendEventHeartbeat(res, req)
+						case value, ok := <-{{$result}}:
+							if !ok {
+								return
+							}
+							err = plumbus.SendEvent(res, req, value{{if $body.ToEvent}}.ToEvent(){{end}})
 						}
-					{{end}}
+						if err != nil {
+							return
+						}
+					}
+				{{else}}
+					if err := plumbus.EncodeBody(res, code, responseCodec, {{$result}}); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+						return
+					}
 				{{end}}
 			{{end}}
 		})
 	})
 }
 `
+
+// paramTemplate parses the parameter described by .arg into .target. When
+// .errors names a variable, problems are added to it rather than responded to
+// immediately. parseValue parses each individual value into a variable named
+// parsed, setting paramErr if it can't.
+const paramTemplate = `{{define "param"}}
+	{{- $arg := .arg}}
+	{
+		{{if eq $arg.ConversionType ConvertPathParam}}
+			var values []string
+			if value, sent := plumbus.PathParam(req, "{{$arg.Name}}"); sent {
+				values = []string{value}
+			}
+		{{else if eq $arg.ConversionType ConvertHeaderParam}}
+			values := req.Header.Values("{{$arg.Name}}")
+		{{else if eq $arg.ConversionType ConvertCookieParam}}
+			var values []string
+			if cookie, err := req.Cookie("{{$arg.Name}}"); err == nil {
+				values = []string{cookie.Value}
+			}
+		{{else}}
+			values := queryParams["{{$arg.Name}}"]
+		{{end}}
+		{{if $arg.IsSlice}}
+			values = plumbus.SplitParamValues(values)
+		{{end}}
+		{{if $arg.Default}}
+			if len(values) == 0 {
+				values = {{if $arg.IsSlice}}plumbus.SplitParamValues({{end}}[]string{ {{- printf "%q" $arg.Default -}} }{{if $arg.IsSlice}}){{end}}
+			}
+		{{end}}
+		var paramErr error
+		{{if $arg.IsPointer}}
+			if len(values) > 0 {
+		{{else}}
+			if len(values) == 0 {
+				paramErr = plumbus.Errorf(
+					http.StatusBadRequest,
+					"missing required {{$arg.ConversionType.ParamLocation}} parameter '{{$arg.Name}}'",
+				)
+			} else {
+		{{end}}
+			{{if $arg.IsSlice}}
+				list := make({{typename $arg.ElemType}}, 0, len(values))
+				for _, value := range values {
+					{{template "parseValue" $arg}}
+					if paramErr != nil {
+						break
+					}
+					list = append(list, parsed)
+				}
+				{{.target}} = {{if $arg.IsPointer}}&{{end}}list
+			{{else}}
+				value := values[0]
+				{{template "parseValue" $arg}}
+				{{.target}} = {{if $arg.IsPointer}}&{{end}}parsed
+			{{end}}
+		}
+		if paramErr != nil {
+			{{if .errors}}
+				{{.errors}} = append({{.errors}}, paramErr)
+			{{else}}
+				plumbus.HandleError(res, req, paramErr, plumbus.SourceParam)
+				return
+			{{end}}
+		}
+	}
+{{end}}
+
+{{define "parseValue"}}
+	{{- $arg := .}}
+	{{if eq $arg.ParamKind ParamString}}
+		parsed := {{typename $arg.ValueType}}(value)
+	{{else if eq $arg.ParamKind ParamInt}}
+		parsedInt, err := strconv.ParseInt(value, 10, {{$arg.ValueType.Bits}})
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be integer value",
+			)
+		}
+		parsed := {{typename $arg.ValueType}}(parsedInt)
+	{{else if eq $arg.ParamKind ParamUint}}
+		parsedUint, err := strconv.ParseUint(value, 10, {{$arg.ValueType.Bits}})
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be non-negative integer value",
+			)
+		}
+		parsed := {{typename $arg.ValueType}}(parsedUint)
+	{{else if eq $arg.ParamKind ParamFloat}}
+		parsedFloat, err := strconv.ParseFloat(value, {{$arg.ValueType.Bits}})
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be number value",
+			)
+		}
+		parsed := {{typename $arg.ValueType}}(parsedFloat)
+	{{else if eq $arg.ParamKind ParamBool}}
+		parsedBool, err := strconv.ParseBool(value)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be boolean value",
+			)
+		}
+		parsed := {{typename $arg.ValueType}}(parsedBool)
+	{{else if eq $arg.ParamKind ParamTime}}
+		parsedTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be RFC 3339 time value",
+			)
+		}
+		parsed := {{typename $arg.ValueType}}(parsedTime)
+	{{else if eq $arg.ParamKind ParamDuration}}
+		parsedDuration, err := time.ParseDuration(value)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' expected to be duration value",
+			)
+		}
+		{{if isStruct $arg.ValueType}}
+			parsed := {{typename $arg.ValueType}}(plumbus.Duration{Duration: parsedDuration})
+		{{else}}
+			parsed := {{typename $arg.ValueType}}(parsedDuration)
+		{{end}}
+	{{else if eq $arg.ParamKind ParamText}}
+		var parsed {{typename $arg.ValueType}}
+		if err := parsed.UnmarshalText([]byte(value)); err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"{{$arg.ConversionType.ParamLocation}} param '{{$arg.Name}}' is invalid: %s",
+				err.Error(),
+			)
+		}
+	{{end}}
+{{end}}`
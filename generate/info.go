@@ -1,17 +1,43 @@
 package generate
 
 import (
+	"context"
+	"encoding"
 	"fmt"
-	"log"
+	"io"
+	"net/http"
 	"reflect"
 	"strings"
+	"time"
+	"unicode"
 )
 
 type ConversionType int
 
 func (ct ConversionType) isQueryParam() bool {
-	return ct == ConvertStringQueryParam ||
-		ct == ConvertIntQueryParam
+	return ct == ConvertQueryParam
+}
+
+// IsParam is true for conversions of a single parameter, as opposed to a
+// struct of parameters
+func (ct ConversionType) IsParam() bool {
+	return ct.ParamLocation() != ""
+}
+
+// ParamLocation is where in the request a parameter is found, or the empty
+// string if the conversion isn't for a parameter
+func (ct ConversionType) ParamLocation() string {
+	switch ct {
+	case ConvertQueryParam:
+		return "query"
+	case ConvertPathParam:
+		return "path"
+	case ConvertHeaderParam:
+		return "header"
+	case ConvertCookieParam:
+		return "cookie"
+	}
+	return ""
 }
 
 const (
@@ -19,25 +45,97 @@ const (
 	ConvertError
 	ConvertCustom
 
-	ConvertStringQueryParam
-	ConvertIntQueryParam
+	ConvertQueryParam
+	ConvertPathParam
+
+	ConvertContext
+	ConvertRequest
+	ConvertResponseWriter
+
+	ConvertHeaderParam
+	ConvertCookieParam
+	ConvertParams
+
+	ConvertReader
+	ConvertChannel
+	ConvertEvents
+
+	ConvertFile
+)
+
+// IsBody is true for the conversions which write the response body
+func (ct ConversionType) IsBody() bool {
+	return ct == ConvertBody || ct == ConvertReader || ct == ConvertChannel || ct == ConvertEvents
+}
+
+// ParamKind is how the string value of a parameter is parsed
+type ParamKind int
+
+const (
+	ParamString ParamKind = iota
+	ParamInt
+	ParamText
+	ParamUint
+	ParamFloat
+	ParamBool
+	ParamTime
+	ParamDuration
 )
 
 type Converter struct {
 	ConversionType ConversionType
+	ParamKind      ParamKind
 	Name           string
 	Type           reflect.Type
 	IsPointer      bool
+	IsSlice        bool
+
+	// for ConvertParams, the converters of each tagged field
+	Fields []*Converter
+
+	// for the converters in Fields
+	FieldName  string
+	FieldIndex int
+	Default    string
+
+	// for ConvertEvents, whether each value is converted by its ToEvent
+	// method rather than being a plumbus.Event already
+	ToEvent bool
+}
+
+// ElemType is the converter's type, without the pointer if it is one
+func (c *Converter) ElemType() reflect.Type {
+	if c.IsPointer {
+		return c.Type.Elem()
+	}
+	return c.Type
+}
+
+// ValueType is the type each value of a parameter is parsed into, which is
+// the element type for parameters that take a slice of values
+func (c *Converter) ValueType() reflect.Type {
+	if c.IsSlice {
+		return c.ElemType().Elem()
+	}
+	return c.ElemType()
 }
 
 type Info struct {
 	Inputs            []*Converter
 	Outputs           []*Converter
 	UsesQueryParams   bool
+	ReadsBody         bool
 	ResponseBodyIndex int
 	LastIsError       bool
 }
 
+// EncodesBody reports whether the response body is written with a codec, and
+// so needs one chosen before the handler is called
+func (info *Info) EncodesBody() bool {
+	return info.ResponseBodyIndex != -1 &&
+		info.Outputs[info.ResponseBodyIndex].ConversionType == ConvertBody
+}
+
 func CollectInfo(typ reflect.Type) (*Info, error) {
 	if typ.Kind() != reflect.Func {
 		return nil, fmt.Errorf(
@@ -51,11 +149,22 @@ func CollectInfo(typ reflect.Type) (*Info, error) {
 	}
 
 	for i := 0; i < typ.NumIn(); i++ {
-		input := inputConverter(typ.In(i))
+		input, err := inputConverter(typ.In(i))
+		if err != nil {
+			return nil, err
+		}
 		info.Inputs = append(info.Inputs, input)
+		if input.ConversionType == ConvertBody || input.ConversionType == ConvertFile {
+			info.ReadsBody = true
+		}
 		if input.ConversionType.isQueryParam() {
 			info.UsesQueryParams = true
 		}
+		for _, field := range input.Fields {
+			if field.ConversionType.isQueryParam() {
+				info.UsesQueryParams = true
+			}
+		}
 	}
 
 	for i := 0; i < typ.NumOut(); i++ {
@@ -64,7 +173,7 @@ func CollectInfo(typ reflect.Type) (*Info, error) {
 		if i == typ.NumOut()-1 {
 			info.LastIsError = output.ConversionType == ConvertError
 		}
-		if output.ConversionType == ConvertBody {
+		if output.ConversionType.IsBody() {
 			//todo: check for multiples here
 			info.ResponseBodyIndex = i
 		}
@@ -80,12 +189,24 @@ func outputConverter(typ reflect.Type) *Converter {
 
 	interfaceType := reflect.TypeOf((*ToResponse)(nil)).Elem()
 	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	readerType := reflect.TypeOf((*io.Reader)(nil)).Elem()
 
 	switch true {
 	case typ.Implements(interfaceType) || reflect.PtrTo(typ).Implements(interfaceType):
 		conv.ConversionType = ConvertCustom
 	case typ.Implements(errorType):
 		conv.ConversionType = ConvertError
+	case typ.Implements(readerType):
+		conv.ConversionType = ConvertReader
+	case typ.Kind() == reflect.Chan && typ.ChanDir()&reflect.RecvDir != 0:
+		conv.ConversionType = ConvertChannel
+		if isEvent(typ.Elem()) {
+			conv.ConversionType = ConvertEvents
+		} else if method, ok := typ.Elem().MethodByName("ToEvent"); ok &&
+			method.Type.NumIn() == 1 && method.Type.NumOut() == 1 && isEvent(method.Type.Out(0)) {
+			conv.ConversionType = ConvertEvents
+			conv.ToEvent = true
+		}
 	default:
 		conv.ConversionType = ConvertBody
 	}
@@ -93,9 +214,42 @@ func outputConverter(typ reflect.Type) *Converter {
 	return conv
 }
 
-func inputConverter(typ reflect.Type) *Converter {
-	if queryParamConverter := typeIsQueryParam(typ); queryParamConverter != nil {
-		return queryParamConverter
+// isEvent checks for plumbus.Event, which can't be referred to directly
+// from this package
+func isEvent(typ reflect.Type) bool {
+	return typ.PkgPath() == "github.com/jargv/plumbus" && typ.Name() == "Event"
+}
+
+func inputConverter(typ reflect.Type) (*Converter, error) {
+	contextType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	requestType := reflect.TypeOf((*http.Request)(nil))
+	responseWriterType := reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
+
+	switch typ {
+	case contextType:
+		return &Converter{
+			Type:           typ,
+			ConversionType: ConvertContext,
+		}, nil
+	case requestType:
+		return &Converter{
+			Type:           typ,
+			IsPointer:      true,
+			ConversionType: ConvertRequest,
+		}, nil
+	case responseWriterType:
+		return &Converter{
+			Type:           typ,
+			ConversionType: ConvertResponseWriter,
+		}, nil
+	}
+
+	if fileConverter := typeIsFile(typ); fileConverter != nil {
+		return fileConverter, nil
+	}
+
+	if paramConverter, err := typeIsParam(typ); paramConverter != nil || err != nil {
+		return paramConverter, err
 	}
 
 	interfaceType := reflect.TypeOf((*FromRequest)(nil)).Elem()
@@ -104,43 +258,316 @@ func inputConverter(typ reflect.Type) *Converter {
 			Type:           typ,
 			IsPointer:      typ.Kind() == reflect.Ptr,
 			ConversionType: ConvertCustom,
-		}
+		}, nil
+	}
+
+	if paramsConverter, err := typeIsParams(typ); paramsConverter != nil || err != nil {
+		return paramsConverter, err
 	}
 
 	return &Converter{
 		Type:           typ,
 		ConversionType: ConvertBody,
+	}, nil
+}
+
+// typeIsFile checks for uploaded files: plumbus.File, *plumbus.File for an
+// optional file, or []plumbus.File for all of them
+func typeIsFile(typ reflect.Type) *Converter {
+	conv := &Converter{
+		Type:           typ,
+		ConversionType: ConvertFile,
+	}
+
+	switch {
+	case isFile(typ):
+	case typ.Kind() == reflect.Ptr && isFile(typ.Elem()):
+		conv.IsPointer = true
+	case typ.Kind() == reflect.Slice && isFile(typ.Elem()):
+		conv.IsSlice = true
+	default:
+		return nil
 	}
+
+	return conv
 }
 
-func typeIsQueryParam(typ reflect.Type) *Converter {
-	const suffix = "QueryParam"
+func isFile(typ reflect.Type) bool {
+	return typ.PkgPath() == "github.com/jargv/plumbus" && typ.Name() == "File"
+}
+
+// typeIsParam checks the name of the type for one of the parameter suffixes,
+// e.g. `type userIdPathParam int` is the path parameter "userId", and
+// `type xTenantIdHeader string` is the header "X-Tenant-Id"
+func typeIsParam(typ reflect.Type) (*Converter, error) {
+	suffixes := []struct {
+		suffix     string
+		conversion ConversionType
+	}{
+		{"QueryParam", ConvertQueryParam},
+		{"PathParam", ConvertPathParam},
+		{"Header", ConvertHeaderParam},
+		{"Cookie", ConvertCookieParam},
+	}
 
-	paramType := typ
 	typeName := typ.Name()
+	if typ.Kind() == reflect.Ptr {
+		typeName = typ.Elem().Name()
+	}
+
+	if !paramShaped(typ) {
+		//e.g. `type PageHeader struct{...}` is a body, not the header "Page"
+		return nil, nil
+	}
+
+	for _, s := range suffixes {
+		if strings.HasSuffix(typeName, s.suffix) {
+			name := strings.TrimSuffix(typeName, s.suffix)
+			if name == "" {
+				//e.g. http.Header or http.Cookie themselves
+				continue
+			}
+			if s.conversion == ConvertHeaderParam {
+				name = headerName(name)
+			}
+			return paramConverter(typ, name, s.conversion)
+		}
+	}
+
+	return nil, nil
+}
+
+// paramShaped checks whether a type could hold a parameter, so that the name
+// suffixes aren't applied to structs and maps that can't be parsed from text
+func paramShaped(typ reflect.Type) bool {
+	textUnmarshalerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice && !reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map:
+		_, ok := paramKind(typ)
+		return ok
+	}
+	return true
+}
+
+// headerName turns a camel case name into a canonical header name, e.g.
+// xTenantId becomes X-Tenant-Id, and requestID becomes Request-Id
+func headerName(name string) string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		//a run of capitals is one word, up until the start of the next
+		afterLower := !unicode.IsUpper(runes[i-1])
+		beforeLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if afterLower || beforeLower {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	return http.CanonicalHeaderKey(strings.Join(words, "-"))
+}
+
+// typeIsParams checks for a struct with fields tagged like
+// `plumbus:"query=limit,default=20"`, each of which is a parameter
+func typeIsParams(typ reflect.Type) (*Converter, error) {
+	structType := typ
+	if typ.Kind() == reflect.Ptr {
+		structType = typ.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	conv := &Converter{
+		ConversionType: ConvertParams,
+		Type:           typ,
+		IsPointer:      typ.Kind() == reflect.Ptr,
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, tagged := field.Tag.Lookup("plumbus")
+		if !tagged {
+			continue
+		}
+
+		if field.PkgPath != "" {
+			return nil, fmt.Errorf("%s.%s: tagged parameter fields must be exported", structType, field.Name)
+		}
+
+		var fieldConv *Converter
+		defaultValue, hasDefault := "", false
+		for _, option := range strings.Split(tag, ",") {
+			key, value := splitTagOption(option)
+
+			if key == "default" {
+				defaultValue, hasDefault = value, true
+				continue
+			}
+
+			conversion, ok := paramLocations[key]
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: unknown plumbus tag option '%s'", structType, field.Name, key)
+			}
+
+			var err error
+			fieldConv, err = paramConverter(field.Type, paramFieldName(field, value), conversion)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", structType, field.Name, err)
+			}
+		}
+
+		if fieldConv == nil {
+			return nil, fmt.Errorf(
+				"%s.%s: plumbus tag needs one of query=, path=, header=, or cookie=",
+				structType,
+				field.Name,
+			)
+		}
+
+		fieldConv.FieldName = field.Name
+		fieldConv.FieldIndex = i
+		if hasDefault {
+			fieldConv.Default = defaultValue
+			//it's never missing, the default is used instead
+			fieldConv.IsPointer = false
+			if field.Type.Kind() == reflect.Ptr {
+				return nil, fmt.Errorf("%s.%s: a parameter with a default can't be a pointer", structType, field.Name)
+			}
+		}
+
+		conv.Fields = append(conv.Fields, fieldConv)
+	}
+
+	if len(conv.Fields) == 0 {
+		return nil, nil
+	}
+
+	return conv, nil
+}
+
+var paramLocations = map[string]ConversionType{
+	"query":  ConvertQueryParam,
+	"path":   ConvertPathParam,
+	"header": ConvertHeaderParam,
+	"cookie": ConvertCookieParam,
+}
+
+// ParamFieldName is the name of the parameter a field of a parameter struct
+// is read from, given by its `plumbus` tag like `plumbus:"query=limit"`, or
+// "" if the tag doesn't give a location
+func ParamFieldName(field reflect.StructField) string {
+	name := ""
+	for _, option := range strings.Split(field.Tag.Get("plumbus"), ",") {
+		key, value := splitTagOption(option)
+		if _, ok := paramLocations[key]; ok {
+			name = paramFieldName(field, value)
+		}
+	}
+	return name
+}
+
+// paramFieldName is the name given in the tag, or the field's name starting
+// with a lower case letter, so a field Page tagged `plumbus:"query"` reads
+// "page"
+func paramFieldName(field reflect.StructField, value string) string {
+	if value == "" {
+		return strings.ToLower(field.Name[:1]) + field.Name[1:]
+	}
+	return value
+}
+
+func splitTagOption(option string) (key, value string) {
+	if eq := strings.Index(option, "="); eq != -1 {
+		return option[:eq], option[eq+1:]
+	}
+	return option, ""
+}
+
+func paramConverter(typ reflect.Type, name string, conversion ConversionType) (*Converter, error) {
+	paramType := typ
 	if typ.Kind() == reflect.Ptr {
 		paramType = typ.Elem()
-		typeName = paramType.Name()
 	}
 
-	if !strings.HasSuffix(typeName, suffix) {
-		return nil
+	//a slice takes every value of a parameter, unless it parses itself
+	textUnmarshalerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	valueType := paramType
+	isSlice := paramType.Kind() == reflect.Slice &&
+		(conversion == ConvertQueryParam || conversion == ConvertHeaderParam) &&
+		!reflect.PtrTo(paramType).Implements(textUnmarshalerType)
+	if isSlice {
+		valueType = paramType.Elem()
 	}
 
-	var conv ConversionType
-	switch paramType.Kind() {
-	case reflect.String:
-		conv = ConvertStringQueryParam
-	case reflect.Int:
-		conv = ConvertIntQueryParam
-	default:
-		log.Fatalf("query parameter types must be string or int kind")
+	kind, ok := paramKind(valueType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s parameter type %s must be of string, bool, integer or float kind, "+
+				"time.Time, time.Duration, plumbus.Duration, or implement encoding.TextUnmarshaler "+
+				"(or be a slice of those, for query and header parameters)",
+			conversion.ParamLocation(),
+			typ,
+		)
 	}
 
 	return &Converter{
-		Name:           strings.TrimSuffix(typeName, suffix),
-		ConversionType: conv,
+		Name:           name,
+		ConversionType: conversion,
+		ParamKind:      kind,
 		Type:           typ,
 		IsPointer:      typ.Kind() == reflect.Ptr,
+		IsSlice:        isSlice,
+	}, nil
+}
+
+// paramKind decides how to parse a parameter of the given type. Since named
+// types don't keep the methods of the type they're defined from, a type like
+// `type sinceQueryParam time.Time` is found by its structure instead. A type
+// defined from time.Duration is indistinguishable from an int64, so durations
+// are either time.Duration itself or defined from plumbus.Duration, which is
+// found by its structure too.
+func paramKind(typ reflect.Type) (ParamKind, bool) {
+	textUnmarshalerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType := reflect.TypeOf(time.Time{})
+	durationType := reflect.TypeOf(time.Duration(0))
+	durationStructType := reflect.TypeOf(struct{ time.Duration }{})
+
+	switch {
+	case typ == durationType:
+		return ParamDuration, true
+	case typ.Kind() == reflect.Struct && typ.ConvertibleTo(durationStructType):
+		return ParamDuration, true
+	case typ.Kind() == reflect.Struct && typ.ConvertibleTo(timeType):
+		return ParamTime, true
+	case reflect.PtrTo(typ).Implements(textUnmarshalerType):
+		return ParamText, true
 	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return ParamString, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ParamInt, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ParamUint, true
+	case reflect.Float32, reflect.Float64:
+		return ParamFloat, true
+	case reflect.Bool:
+		return ParamBool, true
+	}
+
+	return 0, false
 }
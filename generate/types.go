@@ -14,3 +14,7 @@ type FromRequest interface {
 type ToResponse interface {
 	ToResponse(http.ResponseWriter) error
 }
+
+type ResponseCoder interface {
+	ResponseCode() int
+}
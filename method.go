@@ -62,8 +62,11 @@ func (m *method) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	}
 
 	if handler == nil {
-		msg := fmt.Sprintf("method %s not allowed, expected {%s}", m.acceptedMethods)
-		http.Error(res, msg, http.StatusMethodNotAllowed)
+		if m.acceptedMethods != "<none>" {
+			res.Header().Set("Allow", m.acceptedMethods)
+		}
+		msg := fmt.Sprintf("method %s not allowed, expected {%s}", req.Method, m.acceptedMethods)
+		writeRoutingError(res, req, http.StatusMethodNotAllowed, msg)
 		return
 	}
 
@@ -0,0 +1,129 @@
+package plumbus
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Middleware wraps a handler with behavior of its own, like checking
+// authentication or logging requests
+type Middleware func(http.Handler) http.Handler
+
+// Route is a handler with middleware that only applies to it. It can be
+// registered with Handle, or given as one of the handlers in a ByMethod.
+// Middleware runs in the order it's listed. A Route registered with Handle
+// can also have a Name, to build URLs for it with ServeMux.URL.
+type Route struct {
+	Handler    interface{}
+	Middleware []Middleware
+	Name       string
+}
+
+// Group registers routes under a common prefix, each wrapped with the
+// group's middleware
+type Group struct {
+	mux        *ServeMux
+	prefix     string
+	middleware []Middleware
+}
+
+// Use adds middleware which runs for every request to the mux, including
+// those no route matches. Middleware runs in the order it's added.
+func (sm *ServeMux) Use(middleware ...Middleware) {
+	sm.middleware = append(sm.middleware, middleware...)
+	sm.handler = chainMiddleware(sm.Paths, sm.middleware)
+}
+
+// Group makes a group of routes under prefix which use the middleware given
+func (sm *ServeMux) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		mux:        sm,
+		prefix:     prefix,
+		middleware: middleware,
+	}
+}
+
+// Use adds middleware to the routes registered with the group after it
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Group makes a group nested in this one, whose routes use both groups'
+// middleware
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		mux:        g.mux,
+		prefix:     joinRoute(g.prefix, prefix),
+		middleware: append(append([]Middleware{}, g.middleware...), middleware...),
+	}
+}
+
+// Handle registers fn at the route under the group's prefix
+func (g *Group) Handle(route string, fn interface{}, documentation ...string) {
+	handler, middleware := unwrapRoute(fn)
+	g.mux.Handle(joinRoute(g.prefix, route), Route{
+		Handler:    handler,
+		Middleware: append(append([]Middleware{}, g.middleware...), middleware...),
+		Name:       routeName(fn),
+	}, documentation...)
+}
+
+func joinRoute(prefix, route string) string {
+	route = strings.TrimPrefix(route, "/")
+	if route == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + route
+}
+
+// unwrapRoute separates a Route into its handler and middleware, and gives
+// any other handler back as is
+func unwrapRoute(handler interface{}) (interface{}, []Middleware) {
+	switch route := handler.(type) {
+	case Route:
+		return route.Handler, route.Middleware
+	case *Route:
+		return route.Handler, route.Middleware
+	}
+	return handler, nil
+}
+
+func routeName(handler interface{}) string {
+	switch route := handler.(type) {
+	case Route:
+		return route.Name
+	case *Route:
+		return route.Name
+	}
+	return ""
+}
+
+func chainMiddleware(handler http.Handler, middleware []Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// middlewareNames names each middleware by the function that made it, e.g.
+// "RequireAuth" for the closure it returns
+func middlewareNames(middleware []Middleware) []string {
+	names := []string{}
+	for _, mw := range middleware {
+		fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+		if fn == nil {
+			names = append(names, "middleware")
+			continue
+		}
+		name := fn.Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		parts := strings.Split(name, ".")[1:]
+		for len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "func") {
+			parts = parts[:len(parts)-1]
+		}
+		names = append(names, strings.TrimSuffix(strings.Join(parts, "."), "-fm"))
+	}
+	return names
+}
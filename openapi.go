@@ -0,0 +1,486 @@
+package plumbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jargv/plumbus/generate"
+)
+
+// OpenAPI is an OpenAPI 3.1 document describing the routes of a ServeMux
+type OpenAPI struct {
+	OpenAPI    string                      `json:"openapi"`
+	Info       OpenAPIInfo                 `json:"info"`
+	Paths      map[string]*OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents           `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIPathItem struct {
+	Get     *OpenAPIOperation `json:"get,omitempty"`
+	Post    *OpenAPIOperation `json:"post,omitempty"`
+	Put     *OpenAPIOperation `json:"put,omitempty"`
+	Patch   *OpenAPIOperation `json:"patch,omitempty"`
+	Delete  *OpenAPIOperation `json:"delete,omitempty"`
+	Options *OpenAPIOperation `json:"options,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Description string                      `json:"description,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Middleware  []string                    `json:"x-middleware,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+const openAPIErrorSchema = "HTTPError"
+
+const wildcardDescription = "the rest of the path, which may include slashes"
+
+// OpenAPI builds an OpenAPI 3.1 document for every route registered on the
+// ServeMux. Handlers given by method (using ByMethod) are documented under
+// those methods, while other flexible handlers are documented as a "post"
+// if they take a request body and a "get" otherwise.
+func (sm *ServeMux) OpenAPI(title, version string, description ...string) *OpenAPI {
+	schemas := newSchemaCollector("#/components/schemas/%s")
+	schemas.defs[openAPIErrorSchema] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": {Type: "string"},
+		},
+	}
+
+	for i, line := range description {
+		description[i] = cleanupText(line)
+	}
+
+	o := &OpenAPI{
+		OpenAPI: "3.1.0",
+		Info: OpenAPIInfo{
+			Title:       title,
+			Version:     version,
+			Description: strings.Join(description, "\n\n"),
+		},
+		Paths:      map[string]*OpenAPIPathItem{},
+		Components: OpenAPIComponents{Schemas: schemas.defs},
+	}
+
+	flattened := sm.Paths.flatten()
+	routes := make([]string, 0, len(flattened))
+	for path := range flattened {
+		routes = append(routes, path)
+	}
+	sort.Strings(routes)
+
+	//OpenAPI paths can't have constraints, so routes which differ only by
+	//them, or by the names of their variables, would have the same path
+	documented := map[string]string{}
+	for _, path := range routes {
+		segment := flattened[path]
+		docs := cleanupText(strings.Join(segment.documentation, "\n"))
+		item := &OpenAPIPathItem{}
+		handler, middleware := unwrapRoute(segment.originalHandler)
+		collectPathItem(item, schemas, path, handler, docs)
+		item.addMiddleware(middlewareNames(middleware))
+		item.addMiddleware(middlewareNames(sm.middleware))
+
+		apiPath := openAPIPath(path)
+		key := openAPIPathKey(path)
+		existing, collides := documented[key]
+		if !collides {
+			documented[key] = path
+			o.Paths[apiPath] = item
+			continue
+		}
+
+		if openAPIPath(existing) != apiPath {
+			log.Printf(
+				"WARNING: route %s is left out of the OpenAPI document, since it has the same path as route %s",
+				path,
+				existing,
+			)
+			continue
+		}
+		o.Paths[apiPath].merge(item, path, existing)
+	}
+
+	return o
+}
+
+// OpenAPIHandler serves the document built by OpenAPI as json
+func (sm *ServeMux) OpenAPIHandler(title, version string, description ...string) http.Handler {
+	var spec *OpenAPI
+	var lock sync.Mutex
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if spec == nil {
+			spec = sm.OpenAPI(title, version, description...)
+		}
+
+		res.Header().Add("content-type", "application/json")
+		err := json.NewEncoder(res).Encode(spec)
+		if err != nil {
+			log.Printf("encoding openapi document: %v", err)
+		}
+	})
+}
+
+func collectPathItem(item *OpenAPIPathItem, schemas *schemaCollector, path string, handler interface{}, docs string) {
+	switch val := handler.(type) {
+	case http.HandlerFunc, func(http.ResponseWriter, *http.Request), http.Handler:
+		op := &OpenAPIOperation{
+			Description: docs,
+			Responses: map[string]*OpenAPIResponse{
+				"200": {Description: "OK"},
+			},
+		}
+		addPathVariables(op, path)
+		item.Get = op
+
+	case ByMethod:
+		collectMethodOperations(item, schemas, path, &val, docs)
+
+	case *ByMethod:
+		collectMethodOperations(item, schemas, path, val, docs)
+
+	default:
+		op := handlerFunctionToOperation(schemas, path, handler)
+		op.Description = joinDescription(docs, op.Description)
+		if op.RequestBody != nil {
+			item.Post = op
+		} else {
+			item.Get = op
+		}
+	}
+}
+
+// addMiddleware lists middleware which runs before that of each of the
+// item's operations
+func (item *OpenAPIPathItem) addMiddleware(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	for _, op := range []*OpenAPIOperation{item.Get, item.Post, item.Put, item.Patch, item.Delete, item.Options} {
+		if op != nil {
+			op.Middleware = append(append([]string{}, names...), op.Middleware...)
+		}
+	}
+}
+
+// merge adds the operations of another route with the same path, leaving out
+// those for methods the item already has
+func (item *OpenAPIPathItem) merge(other *OpenAPIPathItem, route, existing string) {
+	operations := []struct {
+		method string
+		into   **OpenAPIOperation
+		op     *OpenAPIOperation
+	}{
+		{"GET", &item.Get, other.Get},
+		{"POST", &item.Post, other.Post},
+		{"PUT", &item.Put, other.Put},
+		{"PATCH", &item.Patch, other.Patch},
+		{"DELETE", &item.Delete, other.Delete},
+		{"OPTIONS", &item.Options, other.Options},
+	}
+
+	for _, operation := range operations {
+		if operation.op == nil {
+			continue
+		}
+		if *operation.into != nil {
+			log.Printf(
+				"WARNING: the %s operation of route %s is left out of the OpenAPI document, since route %s has one for the same path",
+				operation.method,
+				route,
+				existing,
+			)
+			continue
+		}
+		*operation.into = operation.op
+	}
+}
+
+func collectMethodOperations(item *OpenAPIPathItem, schemas *schemaCollector, path string, handlers *ByMethod, docs string) {
+	operation := func(handler interface{}) *OpenAPIOperation {
+		if handler == nil {
+			return nil
+		}
+		handler, middleware := unwrapRoute(handler)
+		op := handlerFunctionToOperation(schemas, path, handler)
+		op.Description = joinDescription(docs, op.Description)
+		if len(middleware) > 0 {
+			op.Middleware = middlewareNames(middleware)
+		}
+		return op
+	}
+
+	item.Get = operation(handlers.GET)
+	item.Post = operation(handlers.POST)
+	item.Put = operation(handlers.PUT)
+	item.Patch = operation(handlers.PATCH)
+	item.Delete = operation(handlers.DELETE)
+	item.Options = operation(handlers.OPTIONS)
+}
+
+func handlerFunctionToOperation(schemas *schemaCollector, path string, handler interface{}) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Responses: map[string]*OpenAPIResponse{
+			"default": {
+				Description: "error",
+				Content: map[string]*OpenAPIMediaType{
+					"application/json": {
+						Schema: schemas.ref(openAPIErrorSchema),
+					},
+				},
+			},
+		},
+	}
+
+	typ := reflect.TypeOf(handler)
+	if typ.Kind() != reflect.Func {
+		addPathVariables(op, path)
+		op.Responses["200"] = &OpenAPIResponse{Description: "OK"}
+		return op
+	}
+
+	info, err := generate.CollectInfo(typ)
+	if err != nil {
+		panic(fmt.Errorf("error generating openapi document: %v", err))
+	}
+
+	notes := []string{}
+
+	for _, input := range info.Inputs {
+		switch t := input.ConversionType; t {
+		case generate.ConvertBody:
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: input.Type.Kind() != reflect.Ptr,
+				Content:  openAPIContent(schemas.schema(input.Type)),
+			}
+			if hasFileFields(input.Type) {
+				op.RequestBody.Content = map[string]*OpenAPIMediaType{
+					"multipart/form-data": {Schema: schemas.schema(input.Type)},
+				}
+			}
+		case generate.ConvertFile:
+			file := schemas.schema(input.Type)
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: !input.IsPointer,
+				Content: map[string]*OpenAPIMediaType{
+					"multipart/form-data": {
+						Schema: &Schema{Type: "object", AdditionalProperties: file},
+					},
+				},
+			}
+		case generate.ConvertContext, generate.ConvertRequest, generate.ConvertResponseWriter:
+			//supplied by the request, nothing to document
+		case generate.ConvertCustom:
+			val := reflect.Zero(input.Type).Interface()
+			if doc, ok := val.(documenter); ok {
+				notes = append(notes, cleanupText(doc.Documentation()))
+			}
+		case generate.ConvertQueryParam, generate.ConvertPathParam,
+			generate.ConvertHeaderParam, generate.ConvertCookieParam:
+			op.Parameters = append(op.Parameters, openAPIParam(input))
+		case generate.ConvertParams:
+			for _, field := range input.Fields {
+				op.Parameters = append(op.Parameters, openAPIParam(field))
+			}
+		default:
+			log.Fatalf("unexpected conversion type %d", t)
+		}
+	}
+
+	addPathVariables(op, path)
+
+	status := successStatus(info.Outputs)
+	success := &OpenAPIResponse{Description: http.StatusText(status)}
+	op.Responses[strconv.Itoa(status)] = success
+
+	for _, output := range info.Outputs {
+		switch t := output.ConversionType; t {
+		case generate.ConvertBody:
+			if bodyAllowed(status) {
+				success.Content = openAPIContent(schemas.schema(output.Type))
+			}
+		case generate.ConvertReader:
+			success.Content = map[string]*OpenAPIMediaType{
+				"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}},
+			}
+		case generate.ConvertChannel:
+			success.Content = map[string]*OpenAPIMediaType{
+				"application/x-ndjson": {Schema: schemas.schema(output.Type.Elem())},
+			}
+		case generate.ConvertEvents:
+			event := &Schema{Type: "string"}
+			if output.ToEvent {
+				event = schemas.schema(output.Type.Elem())
+			}
+			success.Content = map[string]*OpenAPIMediaType{
+				"text/event-stream": {Schema: event},
+			}
+		case generate.ConvertCustom:
+			val := reflect.Zero(output.Type).Interface()
+			if doc, ok := val.(documenter); ok {
+				notes = append(notes, cleanupText(doc.Documentation()))
+			}
+		case generate.ConvertError:
+			//covered by the default response
+		default:
+			log.Fatalf("unexpected conversion type %d", t)
+		}
+	}
+
+	op.Description = strings.Join(notes, "\n\n")
+
+	return op
+}
+
+// openAPIContent describes a body in each of the media types that have a
+// codec registered
+func openAPIContent(schema *Schema) map[string]*OpenAPIMediaType {
+	content := map[string]*OpenAPIMediaType{}
+	for _, mediaType := range MediaTypes() {
+		content[mediaType] = &OpenAPIMediaType{Schema: schema}
+	}
+	return content
+}
+
+func openAPIParam(input *generate.Converter) *OpenAPIParameter {
+	t := input.ConversionType
+	param := &OpenAPIParameter{
+		Name:     input.Name,
+		In:       t.ParamLocation(),
+		Required: (!input.IsPointer && input.Default == "") || t == generate.ConvertPathParam,
+		Schema:   paramSchema(input),
+	}
+
+	val := reflect.Zero(input.Type).Interface()
+	if doc, ok := val.(documenter); ok {
+		param.Description = cleanupText(doc.Documentation())
+	}
+
+	return param
+}
+
+func paramSchema(param *generate.Converter) *Schema {
+	s := &Schema{Type: paramTypeName(param.ParamKind)}
+	switch param.ParamKind {
+	case generate.ParamInt, generate.ParamUint, generate.ParamFloat:
+		s.Format = newSchemaCollector("").schema(param.ValueType()).Format
+	case generate.ParamTime:
+		s.Format = "date-time"
+	}
+
+	if param.IsSlice {
+		s = &Schema{Type: "array", Items: s}
+	}
+	if param.Default != "" {
+		s.Default = param.Default
+	}
+	return s
+}
+
+// addPathVariables declares any variables in the route which haven't already
+// been declared as path parameters
+func addPathVariables(op *OpenAPIOperation, path string) {
+	declared := map[string]*OpenAPIParameter{}
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			declared[param.Name] = param
+		}
+	}
+	for _, variable := range routeVariables(path) {
+		if param, ok := declared[variable.name]; ok {
+			if variable.constraint != nil && param.Schema.Type == "string" {
+				param.Schema.Format = variable.constraint.schema().Format
+				param.Schema.Pattern = variable.constraint.schema().Pattern
+			}
+			continue
+		}
+		param := &OpenAPIParameter{
+			Name:     variable.name,
+			In:       "path",
+			Required: true,
+			Schema:   variable.constraint.schema(),
+		}
+		if variable.isWildcard {
+			param.Description = wildcardDescription
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+}
+
+// openAPIPath converts a route like /user/:userId to /user/{userId}. A
+// wildcard becomes a parameter too, though OpenAPI has no way to say it can
+// hold slashes other than its description.
+func openAPIPath(path string) string {
+	segments := getSegments(path)
+	for i, segment := range segments {
+		if name, _, ok := pathVariable(segment); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// openAPIPathKey is the OpenAPI path without the names of its variables,
+// which is the same for every route OpenAPI considers the same path
+func openAPIPathKey(path string) string {
+	segments := getSegments(path)
+	for i, segment := range segments {
+		if _, _, ok := pathVariable(segment); ok {
+			segments[i] = "{}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func joinDescription(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
@@ -0,0 +1,58 @@
+package plumbus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered from a handler, and where it happened
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Unwrap gives the value of the panic if it was an error
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
+// PanicReporterFunc is told about each panic recovered while handling req
+type PanicReporterFunc func(req *http.Request, err *PanicError)
+
+// RecoverPanic turns a panic while handling the request into an error
+// response, after reporting it to the PanicReporter of the ServeMux serving
+// the request, or logging it with its stack if there isn't one. It must be
+// deferred directly. http.ErrAbortHandler is panicked again, so the response
+// is still aborted.
+func RecoverPanic(res http.ResponseWriter, req *http.Request) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(value)
+	}
+
+	err := &PanicError{Value: value, Stack: debug.Stack()}
+	if sm := serveMuxOf(req); sm != nil && sm.PanicReporter != nil {
+		sm.PanicReporter(req, err)
+	} else {
+		log.Printf(
+			"panic handling request: %s %s: %v\n%s",
+			req.Method,
+			req.URL.Path,
+			value,
+			err.Stack,
+		)
+	}
+
+	HandleError(res, req, err, SourcePanic)
+}
@@ -1,27 +1,90 @@
 package plumbus
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/jargv/plumbus/generate"
 )
 
 type Paths struct {
 	handler         http.Handler
 	subpaths        map[string]*Paths
 	variables       map[string]*Paths
+	wildcard        *Paths
+	wildcardName    string
+	variableName    string
+	constraint      *pathConstraint
+	variableOrder   []*Paths
 	documentation   []string
 	originalHandler interface{}
+
+	//routes maps the pattern of each route registered, with the names of
+	//its variables left out, to the route itself
+	routes map[string]string
 }
 
+// Handle registers the handler for the route. Segments of the route are
+// either literals, :variables, which may have a constraint like :id(int), or
+// a *wildcard at the end which matches the rest of the path. Requests are
+// matched one segment at a time, trying literals, then variables with built
+// in constraints, then variables with regular expressions, then variables
+// without constraints, then wildcards, and backing up to the next choice
+// when the rest of the path doesn't match. Routes which would match exactly
+// the same paths conflict, and panic.
 func (p *Paths) Handle(path string, handler interface{}, documentation ...string) {
 	segments := getSegments(path)
-	success := p.insertSegments(segments, handler, documentation)
-	if !success {
-		//todo: add the route to this message
+
+	pattern, err := routePattern(segments)
+	if err != nil {
+		panic(err)
+	}
+	if existing, exists := p.routes[pattern]; exists {
+		panic(fmt.Errorf("route %s conflicts with %s", path, existing))
+	}
+	if err := checkPathParams(path, handler); err != nil {
+		panic(err)
+	}
+
+	if !p.insertSegments(segments, handler, documentation) {
 		panic(fmt.Errorf("duplicate route for path %s", path))
 	}
+
+	if p.routes == nil {
+		p.routes = map[string]string{}
+	}
+	p.routes[pattern] = path
+}
+
+// routePattern describes the paths a route matches, so that routes which
+// only differ by the names of their variables are the same
+func routePattern(segments []string) (string, error) {
+	pattern := make([]string, len(segments))
+	for i, segment := range segments {
+		switch {
+		case segment == "":
+			pattern[i] = segment
+		case segment[0] == '*':
+			pattern[i] = "*"
+		case segment[0] == ':':
+			_, constraint, err := parsePathVariable(segment[1:])
+			if err != nil {
+				return "", err
+			}
+			pattern[i] = ":"
+			if constraint != nil {
+				pattern[i] += "(" + constraint.source + ")"
+			}
+		default:
+			pattern[i] = segment
+		}
+	}
+	return "/" + strings.Join(pattern, "/"), nil
 }
 
 func (p *Paths) insertSegments(segments []string, handler interface{}, documentation []string) bool {
@@ -44,32 +107,60 @@ func (p *Paths) insertSegments(segments []string, handler interface{}, documenta
 
 	segment := segments[0]
 
+	if segment != "" && segment[0] == '*' {
+		if len(segments) > 1 {
+			panic(fmt.Errorf("wildcard segment %s must be the last in the route", segment))
+		}
+		if p.wildcard != nil && p.wildcardName != segment[1:] {
+			return false
+		}
+		if p.wildcard == nil {
+			p.wildcard = &Paths{}
+			p.wildcardName = segment[1:]
+		}
+		return p.wildcard.insertSegments(nil, handler, documentation)
+	}
+
 	insertMap := p.subpaths
-	//todo: check length first
-	if segment[0] == ':' {
+	var name string
+	var constraint *pathConstraint
+	isVariable := segment != "" && segment[0] == ':'
+	if isVariable {
 		insertMap = p.variables
 		segment = segment[1:]
+		var err error
+		name, constraint, err = parsePathVariable(segment)
+		if err != nil {
+			panic(err)
+		}
 	}
 
 	sub, exists := insertMap[segment]
 	if !exists {
-		sub = &Paths{}
+		sub = &Paths{variableName: name, constraint: constraint}
 		insertMap[segment] = sub
+		if isVariable {
+			p.addVariable(sub)
+		}
 	}
 
 	return sub.insertSegments(segments[1:], handler, documentation)
 }
 
-func (p *Paths) findHandler(url *url.URL) http.Handler {
-	segments := getSegments(url.Path)
-	vals := url.Query()
-	handler := p.findHandlerSegments(segments, vals)
-	url.RawQuery = vals.Encode()
-	return handler
+// findHandler finds the handler for the path, and the values of the path
+// parameters it matched
+func (p *Paths) findHandler(path string) (http.Handler, map[string]string) {
+	params := map[string]string{}
+	handler := p.findHandlerSegments(getSegments(path), params)
+	return handler, params
 }
 
-func (p *Paths) findHandlerSegments(segments []string, query url.Values) http.Handler {
+func (p *Paths) findHandlerSegments(segments []string, params map[string]string) http.Handler {
 	if len(segments) == 0 {
+		if p.handler == nil && p.wildcard != nil {
+			//a wildcard matches an empty remainder too
+			return p.matchWildcard(segments, params)
+		}
 		return p.handler
 	}
 
@@ -78,32 +169,84 @@ func (p *Paths) findHandlerSegments(segments []string, query url.Values) http.Ha
 	sub, found := p.subpaths[segment]
 	if found {
 		//if no match, we might have a variable match instead
-		if res := sub.findHandlerSegments(segments[1:], query); res != nil {
+		if res := sub.findHandlerSegments(segments[1:], params); res != nil {
 			return res
 		}
 	}
 
 	//it's either a variable or not found
-	for varName, sub := range p.variables {
-		if handler := sub.findHandlerSegments(segments[1:], query); handler != nil {
-			query.Add(varName, segment)
+	for _, sub := range p.variableOrder {
+		if !sub.constraint.matches(segment) {
+			continue
+		}
+		if handler := sub.findHandlerSegments(segments[1:], params); handler != nil {
+			params[sub.variableName] = segment
 			return handler
 		}
 	}
 
+	if p.wildcard != nil {
+		return p.matchWildcard(segments, params)
+	}
+
 	return nil
 }
 
+// addVariable adds to the order variables are tried in: those with built in
+// constraints, then those with regular expressions, then those that match
+// anything, each in the order they were added
+func (p *Paths) addVariable(sub *Paths) {
+	p.variableOrder = append(p.variableOrder, sub)
+	sort.SliceStable(p.variableOrder, func(i, j int) bool {
+		return p.variableOrder[i].constraint.rank() < p.variableOrder[j].constraint.rank()
+	})
+}
+
+// matchWildcard captures the rest of the path, slashes and all
+func (p *Paths) matchWildcard(segments []string, params map[string]string) http.Handler {
+	value := strings.Join(segments, "/")
+	params[p.wildcardName] = value
+	return p.wildcard.handler
+}
+
 func (p *Paths) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	handler := p.findHandler(req.URL)
+	handler, params := p.findHandler(req.URL.Path)
 	if handler == nil {
-		http.Error(res, fmt.Sprintf("not found %s", req.URL.String()), http.StatusNotFound)
+		writeRoutingError(res, req, http.StatusNotFound, fmt.Sprintf("not found %s", req.URL.String()))
 		return
 	}
 
+	if len(params) > 0 {
+		req = withPathParams(req, params)
+	}
+
 	handler.ServeHTTP(res, req)
 }
 
+type pathParamsKey struct{}
+
+// withPathParams adds the path parameters to the request's context, keeping
+// any found by an enclosing Paths
+func withPathParams(req *http.Request, params map[string]string) *http.Request {
+	if outer, ok := req.Context().Value(pathParamsKey{}).(map[string]string); ok {
+		for name, value := range outer {
+			if _, exists := params[name]; !exists {
+				params[name] = value
+			}
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), pathParamsKey{}, params))
+}
+
+// PathParam gets the value of the path parameter called name, e.g. "userId"
+// for the route "/user/:userId". The second result is false if the route
+// that matched the request had no such parameter.
+func PathParam(req *http.Request, name string) (string, bool) {
+	params, _ := req.Context().Value(pathParamsKey{}).(map[string]string)
+	value, ok := params[name]
+	return value, ok
+}
+
 func (p *Paths) flatten() map[string]*Paths {
 	res := map[string]*Paths{}
 	p.flattenMap("", res)
@@ -112,7 +255,11 @@ func (p *Paths) flatten() map[string]*Paths {
 
 func (p *Paths) flattenMap(path string, m map[string]*Paths) {
 	if p.originalHandler != nil {
-		m[path] = p
+		route := path
+		if route == "" {
+			route = "/"
+		}
+		m[route] = p
 	}
 	for p, sub := range p.subpaths {
 		sub.flattenMap(path+"/"+p, m)
@@ -120,9 +267,174 @@ func (p *Paths) flattenMap(path string, m map[string]*Paths) {
 	for p, sub := range p.variables {
 		sub.flattenMap(path+"/:"+p, m)
 	}
+	if p.wildcard != nil {
+		p.wildcard.flattenMap(path+"/*"+p.wildcardName, m)
+	}
 }
 
 func getSegments(path string) []string {
 	sansSlash := strings.TrimPrefix(strings.TrimSuffix(path, "/"), "/")
+	if sansSlash == "" {
+		return []string{}
+	}
 	return strings.Split(sansSlash, "/")
 }
+
+// pathVariable gets the name of the variable in a route segment, which is
+// either a :variable or a *wildcard
+func pathVariable(segment string) (name string, isWildcard bool, ok bool) {
+	if len(segment) < 2 {
+		return "", false, false
+	}
+	switch segment[0] {
+	case ':':
+		name, _, _ := parsePathVariable(segment[1:])
+		return name, false, true
+	case '*':
+		return segment[1:], true, true
+	}
+	return "", false, false
+}
+
+// routeVariable is a variable in a route, and the constraint on its values
+type routeVariable struct {
+	name       string
+	isWildcard bool
+	constraint *pathConstraint
+}
+
+func routeVariables(path string) []routeVariable {
+	variables := []routeVariable{}
+	for _, segment := range getSegments(path) {
+		name, isWildcard, ok := pathVariable(segment)
+		if !ok {
+			continue
+		}
+		variable := routeVariable{name: name, isWildcard: isWildcard}
+		if !isWildcard {
+			_, variable.constraint, _ = parsePathVariable(segment[1:])
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// checkPathParams makes sure each path parameter the handler takes, as an
+// argument or a field of a parameter struct, is a variable of the route.
+// Otherwise every request to the route would be missing it.
+func checkPathParams(path string, handler interface{}) error {
+	variables := map[string]bool{}
+	for _, variable := range routeVariables(path) {
+		variables[variable.name] = true
+	}
+
+	for _, fn := range handlerFunctions(handler) {
+		info, err := generate.CollectInfo(reflect.TypeOf(fn))
+		if err != nil {
+			//reported when the handler is adapted
+			continue
+		}
+
+		converters := []*generate.Converter{}
+		for _, input := range info.Inputs {
+			converters = append(append(converters, input), input.Fields...)
+		}
+		for _, converter := range converters {
+			if converter.ConversionType == generate.ConvertPathParam && !variables[converter.Name] {
+				return fmt.Errorf("route %s has no variable for path parameter '%s'", path, converter.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// handlerFunctions finds the functions within a handler which are adapted,
+// as opposed to those which are already http handlers
+func handlerFunctions(handler interface{}) []interface{} {
+	switch val := handler.(type) {
+	case nil, func(http.ResponseWriter, *http.Request), http.Handler:
+		return nil
+	case ByMethod:
+		return handlerFunctions(&val)
+	case *ByMethod:
+		functions := []interface{}{}
+		for _, method := range []interface{}{val.GET, val.POST, val.PUT, val.PATCH, val.DELETE, val.OPTIONS} {
+			functions = append(functions, handlerFunctions(method)...)
+		}
+		return functions
+	case Route:
+		return handlerFunctions(val.Handler)
+	case *Route:
+		return handlerFunctions(val.Handler)
+	}
+
+	if reflect.TypeOf(handler).Kind() != reflect.Func {
+		return nil
+	}
+	return []interface{}{handler}
+}
+
+// pathConstraint limits the values a path variable matches. It's written
+// after the variable's name in parentheses, as either the name of a built in
+// constraint or a regular expression, like :id(int) or :slug([a-z0-9-]+).
+type pathConstraint struct {
+	source  string
+	pattern *regexp.Regexp
+}
+
+// pathConstraints are the built in constraints, by name
+var pathConstraints = map[string]string{
+	"int":  `-?[0-9]+`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// parsePathVariable splits a variable like id(int) into its name and
+// constraint, which is nil if it has none
+func parsePathVariable(spec string) (string, *pathConstraint, error) {
+	open := strings.Index(spec, "(")
+	if open == -1 {
+		return spec, nil, nil
+	}
+	if !strings.HasSuffix(spec, ")") {
+		return "", nil, fmt.Errorf("unterminated constraint in path variable :%s", spec)
+	}
+
+	name, source := spec[:open], spec[open+1:len(spec)-1]
+	expr, builtin := pathConstraints[source]
+	if !builtin {
+		expr = source
+	}
+	pattern, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid constraint for path variable :%s: %v", name, err)
+	}
+
+	return name, &pathConstraint{source: source, pattern: pattern}, nil
+}
+
+func (pc *pathConstraint) rank() int {
+	switch {
+	case pc == nil:
+		return 2
+	case pathConstraints[pc.source] != "":
+		return 0
+	}
+	return 1
+}
+
+func (pc *pathConstraint) matches(value string) bool {
+	return pc == nil || pc.pattern.MatchString(value)
+}
+
+// schema describes the values matched, for documentation
+func (pc *pathConstraint) schema() *Schema {
+	switch {
+	case pc == nil:
+		return &Schema{Type: "string"}
+	case pc.source == "int":
+		return &Schema{Type: "integer"}
+	case pc.source == "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	return &Schema{Type: "string", Pattern: "^(?:" + pc.source + ")$"}
+}
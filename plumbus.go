@@ -33,6 +33,25 @@ func RegisterAdaptor(typ reflect.Type, adaptor adaptorFunc) {
 
 type ServeMux struct {
 	*Paths
+
+	// ProblemDetails sends every error from the mux, including those for
+	// requests no route matches, as an RFC 9457 application/problem+json
+	// object instead of the default formats
+	ProblemDetails bool
+
+	// ErrorHandler, if set, responds to every error from the mux instead of
+	// HandleResponseError, which it can still call for errors it doesn't
+	// want to handle itself
+	ErrorHandler ErrorHandlerFunc
+
+	// PanicReporter, if set, is told about every panic recovered from a
+	// handler, instead of it being logged. The panic is then sent to the
+	// error handler as a 500.
+	PanicReporter PanicReporterFunc
+
+	middleware []Middleware
+	handler    http.Handler
+	names      map[string]string
 }
 
 func NewServeMux() *ServeMux {
@@ -49,7 +68,27 @@ func (sm *ServeMux) Handle(route string, fn interface{}, documentation ...string
 		}
 	}()
 
+	name := routeName(fn)
+	if existing, exists := sm.names[name]; name != "" && exists {
+		panic(fmt.Errorf("route name %q is already used by %s", name, existing))
+	}
+
 	sm.Paths.Handle(route, fn, documentation...)
+
+	if name != "" {
+		if sm.names == nil {
+			sm.names = map[string]string{}
+		}
+		sm.names[name] = route
+	}
+}
+
+func (sm *ServeMux) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	handler := sm.handler
+	if handler == nil {
+		handler = sm.Paths
+	}
+	handler.ServeHTTP(res, withServeMux(req, sm))
 }
 
 func HandlerFunc(handler interface{}) http.Handler {
@@ -62,6 +101,10 @@ func HandlerFunc(handler interface{}) http.Handler {
 		return val.compile()
 	case *ByMethod:
 		return val.compile()
+	case Route:
+		return chainMiddleware(HandlerFunc(val.Handler), val.Middleware)
+	case *Route:
+		return chainMiddleware(HandlerFunc(val.Handler), val.Middleware)
 	}
 
 	typ := reflect.TypeOf(handler)
@@ -96,23 +139,104 @@ func makeDynamicAdaptor(typ reflect.Type) adaptorFunc {
 		if err != nil {
 			panic(err)
 		}
+		for _, input := range info.Inputs {
+			t := input.ConversionType
+			if t == generate.ConvertBody || t == generate.ConvertParams {
+				if err := CheckValidateTags(input.Type); err != nil {
+					panic(err)
+				}
+			}
+		}
 		return infoToDynamicAdaptor(info, val)
 	}
 }
 
+// ErrorSource is the step of handling a request where an error arose
+type ErrorSource int
+
+const (
+	// SourceRouting is for requests with no route, or no handler for their method
+	SourceRouting ErrorSource = iota
+	// SourceDecode is for request bodies and uploaded files that can't be read
+	SourceDecode
+	// SourceParam is for missing or invalid parameters, and errors from FromRequest
+	SourceParam
+	// SourceValidation is for arguments that fail validation
+	SourceValidation
+	// SourceHandler is for errors returned by the handler itself
+	SourceHandler
+	// SourceEncode is for errors from ToResponse, and response bodies that
+	// can't be written
+	SourceEncode
+	// SourcePanic is for panics recovered from the handler
+	SourcePanic
+)
+
+func (es ErrorSource) String() string {
+	switch es {
+	case SourceRouting:
+		return "routing"
+	case SourceDecode:
+		return "decode"
+	case SourceParam:
+		return "param"
+	case SourceValidation:
+		return "validation"
+	case SourceHandler:
+		return "handler"
+	case SourceEncode:
+		return "encode"
+	case SourcePanic:
+		return "panic"
+	}
+	return fmt.Sprintf("ErrorSource(%d)", int(es))
+}
+
+// ErrorHandlerFunc responds to an error that arose while handling req
+type ErrorHandlerFunc func(res http.ResponseWriter, req *http.Request, err error, source ErrorSource)
+
+// HandleError responds to an error with the ErrorHandler of the ServeMux
+// serving the request, or with HandleResponseError if it doesn't have one
+func HandleError(res http.ResponseWriter, req *http.Request, err error, source ErrorSource) {
+	if sm := serveMuxOf(req); sm != nil && sm.ErrorHandler != nil {
+		sm.ErrorHandler(res, req, err, source)
+		return
+	}
+	HandleResponseError(res, req, err)
+}
+
+// HandleResponseError is the default response to an error. HTTPErrors are
+// sent with their response code and message, and anything else is logged
+// and sent as a 500.
 func HandleResponseError(res http.ResponseWriter, req *http.Request, err error) {
-	if httperr, ok := err.(HTTPError); ok {
-		res.WriteHeader(httperr.ResponseCode())
-		json.NewEncoder(res).Encode(map[string]interface{}{
-			"error": httperr.Error(),
-		})
-	} else {
+	httperr, ok := AsHTTPError(err)
+	//panics were already logged or reported by RecoverPanic
+	var panicErr *PanicError
+	if !ok && !errors.As(err, &panicErr) {
 		log.Printf(
 			"error handling request: %s %s: %v",
 			req.Method,
 			req.URL.Path,
 			err,
 		)
+	}
+
+	if problemDetailsEnabled(req) {
+		writeProblem(res, req, err)
+		return
+	}
+
+	if ok {
+		body := map[string]interface{}{
+			"error": httperr.Error(),
+		}
+		var fields fieldErrorer
+		if errors.As(err, &fields) {
+			body["fields"] = fields.FieldErrors()
+		}
+		res.WriteHeader(httperr.ResponseCode())
+		json.NewEncoder(res).Encode(body)
+	} else {
 		body := `{"error":"internal server error"}`
 		http.Error(res, body, http.StatusInternalServerError)
 	}
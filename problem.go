@@ -0,0 +1,92 @@
+package plumbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// ProblemExtender can be implemented by an error to add members to its
+// problem details. Members named "type" or "title" replace the defaults.
+type ProblemExtender interface {
+	ProblemExtensions() map[string]interface{}
+}
+
+type serveMuxKey struct{}
+
+// withServeMux adds the mux serving the request to its context, so errors
+// can be written the way it's configured to
+func withServeMux(req *http.Request, sm *ServeMux) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), serveMuxKey{}, sm))
+}
+
+func serveMuxOf(req *http.Request) *ServeMux {
+	sm, _ := req.Context().Value(serveMuxKey{}).(*ServeMux)
+	return sm
+}
+
+func problemDetailsEnabled(req *http.Request) bool {
+	sm := serveMuxOf(req)
+	return sm != nil && sm.ProblemDetails
+}
+
+// writeProblem sends err as an RFC 9457 problem details object. Errors which
+// aren't HTTPErrors are sent as a 500 without their details.
+func writeProblem(res http.ResponseWriter, req *http.Request, err error) {
+	status := http.StatusInternalServerError
+	problem := map[string]interface{}{}
+
+	if httperr, ok := AsHTTPError(err); ok {
+		status = httperr.ResponseCode()
+		problem["detail"] = httperr.Error()
+		var fields fieldErrorer
+		if errors.As(err, &fields) {
+			problem["fields"] = fields.FieldErrors()
+		}
+	}
+
+	var extender ProblemExtender
+	if errors.As(err, &extender) {
+		for name, value := range extender.ProblemExtensions() {
+			switch name {
+			case "status", "detail", "instance":
+				//these always describe the response
+			default:
+				problem[name] = value
+			}
+		}
+	}
+
+	if _, ok := problem["type"]; !ok {
+		problem["type"] = "about:blank"
+	}
+	if _, ok := problem["title"]; !ok {
+		problem["title"] = http.StatusText(status)
+	}
+	problem["status"] = status
+	problem["instance"] = req.URL.Path
+
+	res.Header().Set("Content-Type", "application/problem+json")
+	res.Header().Del("Content-Length")
+	res.WriteHeader(status)
+	if err := json.NewEncoder(res).Encode(problem); err != nil {
+		log.Printf("error writing problem details: %v", err)
+	}
+}
+
+// writeRoutingError sends the error for a request that no handler matched,
+// as plain text unless the mux has an error handler or problem details are
+// enabled
+func writeRoutingError(res http.ResponseWriter, req *http.Request, code int, msg string) {
+	if sm := serveMuxOf(req); sm != nil && sm.ErrorHandler != nil {
+		sm.ErrorHandler(res, req, Error(code, msg), SourceRouting)
+		return
+	}
+	if problemDetailsEnabled(req) {
+		writeProblem(res, req, Error(code, msg))
+		return
+	}
+	http.Error(res, msg, code)
+}
@@ -1,32 +1,53 @@
 package plumbus
 
 import (
-	"encoding/json"
-	"fmt"
+	"encoding"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jargv/plumbus/generate"
 )
 
 func infoToDynamicAdaptor(info *generate.Info, handler reflect.Value) http.HandlerFunc {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		defer RecoverPanic(res, req)
+
 		var queryParams url.Values
 		if info.UsesQueryParams {
 			queryParams = req.URL.Query()
 		}
 
+		if info.ReadsBody {
+			defer CleanupMultipart(req)
+		}
+
+		var responseCodec ResponseCodec
+		if info.EncodesBody() {
+			var err error
+			responseCodec, err = NegotiateCodec(req)
+			if err != nil {
+				HandleError(res, req, err, SourceEncode)
+				return
+			}
+		}
+
 		args := make([]reflect.Value, len(info.Inputs))
 		for i, converter := range info.Inputs {
 			val := reflect.New(converter.Type)
 			switch t := converter.ConversionType; t {
 			case generate.ConvertBody:
-				if err := json.NewDecoder(req.Body).Decode(val.Interface()); err != nil {
-					msg := fmt.Sprintf(`{"error": "decoding json: %s"}`, err.Error())
-					http.Error(res, msg, http.StatusBadRequest)
+				if err := DecodeBody(req, val.Interface()); err != nil {
+					HandleError(res, req, err, SourceDecode)
+					return
+				}
+				if err := Validate(val.Elem().Interface()); err != nil {
+					HandleError(res, req, err, SourceValidation)
 					return
 				}
 			case generate.ConvertCustom:
@@ -37,17 +58,40 @@ func infoToDynamicAdaptor(info *generate.Info, handler reflect.Value) http.Handl
 				}
 				err := interfaceVal.Interface().(FromRequest).FromRequest(req)
 				if err != nil {
-					HandleResponseError(res, req, err)
+					HandleError(res, req, err, SourceParam)
+					return
+				}
+			case generate.ConvertFile:
+				if err := BindFiles(req, val.Interface()); err != nil {
+					HandleError(res, req, err, SourceDecode)
 					return
 				}
-			case generate.ConvertStringQueryParam, generate.ConvertIntQueryParam:
-				err := getQueryParam(converter, val, queryParams)
+			case generate.ConvertContext:
+				val.Elem().Set(reflect.ValueOf(req.Context()))
+			case generate.ConvertRequest:
+				val.Elem().Set(reflect.ValueOf(req))
+			case generate.ConvertResponseWriter:
+				val.Elem().Set(reflect.ValueOf(res))
+			case generate.ConvertQueryParam, generate.ConvertPathParam,
+				generate.ConvertHeaderParam, generate.ConvertCookieParam:
+				values := paramValues(converter, req, queryParams)
+				err := getParam(converter, val, values)
 				if err != nil {
-					HandleResponseError(res, req, err)
+					HandleError(res, req, err, SourceParam)
+					return
+				}
+			case generate.ConvertParams:
+				err := getParams(converter, val, req, queryParams)
+				if err != nil {
+					HandleError(res, req, err, SourceParam)
+					return
+				}
+				if err := Validate(val.Elem().Interface()); err != nil {
+					HandleError(res, req, err, SourceValidation)
 					return
 				}
 			default:
-				log.Fatalf("unexpected Convert Type: %s", t)
+				log.Fatalf("unexpected Convert Type: %d", t)
 			}
 			args[i] = val.Elem()
 		}
@@ -57,7 +101,7 @@ func infoToDynamicAdaptor(info *generate.Info, handler reflect.Value) http.Handl
 			last := results[len(results)-1]
 			if !last.IsNil() {
 				err := last.Interface().(error)
-				HandleResponseError(res, req, err)
+				HandleError(res, req, err, SourceHandler)
 				return
 			}
 		}
@@ -66,38 +110,131 @@ func infoToDynamicAdaptor(info *generate.Info, handler reflect.Value) http.Handl
 			switch t := converter.ConversionType; t {
 			case generate.ConvertError:
 				//this would have been handled above if there were an error
-			case generate.ConvertBody:
+			case generate.ConvertBody, generate.ConvertReader, generate.ConvertChannel, generate.ConvertEvents:
 				//do nothing, the response body has to be sent last
 			case generate.ConvertCustom:
 				err := results[i].Interface().(ToResponse).ToResponse(res)
 				if err != nil {
-					HandleResponseError(res, req, err)
+					HandleError(res, req, err, SourceEncode)
 					return
 				}
 			default:
-				log.Fatalf("unexpected Convert Type: %s", t)
+				log.Fatalf("unexpected Convert Type: %d", t)
 			}
 		}
 
-		if info.ResponseBodyIndex != -1 {
-			enc := json.NewEncoder(res)
-			err := enc.Encode(results[info.ResponseBodyIndex].Interface())
+		values := []interface{}{}
+		for i, converter := range info.Outputs {
+			if converter.ConversionType != generate.ConvertError {
+				values = append(values, results[i].Interface())
+			}
+		}
+		code := ResponseCodeOf(values...)
+
+		if info.ResponseBodyIndex == -1 {
+			WriteResponseCode(res, code)
+			return
+		}
+
+		body := results[info.ResponseBodyIndex]
+		switch t := info.Outputs[info.ResponseBodyIndex].ConversionType; t {
+		case generate.ConvertBody:
+			err := EncodeBody(res, code, responseCodec, body.Interface())
 			if err != nil {
-				HandleResponseError(res, req, err)
+				HandleError(res, req, err, SourceEncode)
 				return
 			}
+		case generate.ConvertReader:
+			reader, _ := body.Interface().(io.Reader)
+			StreamReader(res, req, code, reader)
+		case generate.ConvertChannel:
+			StreamChannel(res, req, code, body.Interface())
+		case generate.ConvertEvents:
+			StreamEvents(res, req, code, body.Interface())
+		default:
+			log.Fatalf("unexpected Convert Type: %d", t)
 		}
 	})
 }
 
-func getQueryParam(converter *generate.Converter, val reflect.Value, queryParams url.Values) error {
-	t := converter.ConversionType
-	_, sent := queryParams[converter.Name]
+// SplitParamValues gets the values of a parameter which takes a slice,
+// allowing both repeated (?tag=a&tag=b) and comma separated (?tag=a,b) forms
+func SplitParamValues(values []string) []string {
+	split := []string{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part != "" {
+				split = append(split, part)
+			}
+		}
+	}
+	return split
+}
+
+// paramValues gets the values sent for a parameter, or its default
+func paramValues(converter *generate.Converter, req *http.Request, queryParams url.Values) []string {
+	var values []string
+	switch converter.ConversionType {
+	case generate.ConvertQueryParam:
+		values = queryParams[converter.Name]
+	case generate.ConvertPathParam:
+		if value, sent := PathParam(req, converter.Name); sent {
+			values = []string{value}
+		}
+	case generate.ConvertHeaderParam:
+		values = req.Header.Values(converter.Name)
+	case generate.ConvertCookieParam:
+		if cookie, err := req.Cookie(converter.Name); err == nil {
+			values = []string{cookie.Value}
+		}
+	}
+
+	if converter.IsSlice {
+		values = SplitParamValues(values)
+	}
+
+	if len(values) == 0 && converter.Default != "" {
+		values = []string{converter.Default}
+		if converter.IsSlice {
+			values = SplitParamValues(values)
+		}
+	}
+
+	return values
+}
+
+// getParams fills each tagged field of the struct val points to, reporting
+// the problems with all of them together
+func getParams(converter *generate.Converter, val reflect.Value, req *http.Request, queryParams url.Values) error {
+	structVal := val.Elem()
+	if converter.IsPointer {
+		structVal.Set(reflect.New(converter.Type.Elem()))
+		structVal = structVal.Elem()
+	}
+
+	var errs ParamErrors
+	for _, field := range converter.Fields {
+		values := paramValues(field, req, queryParams)
+		fieldVal := structVal.Field(field.FieldIndex).Addr()
+		if err := getParam(field, fieldVal, values); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func getParam(converter *generate.Converter, val reflect.Value, values []string) error {
+	sent := len(values) > 0
 
 	if !sent && !converter.IsPointer {
 		return Errorf(
 			http.StatusBadRequest,
-			"missing required query parameter '%s'",
+			"missing required %s parameter '%s'",
+			converter.ConversionType.ParamLocation(),
 			converter.Name,
 		)
 	}
@@ -106,28 +243,100 @@ func getQueryParam(converter *generate.Converter, val reflect.Value, queryParams
 		return nil
 	}
 
-	paramString := queryParams.Get(converter.Name)
-
 	setVal := val
 	if converter.IsPointer {
 		val.Elem().Set(reflect.New(converter.Type.Elem()))
 		setVal = val.Elem()
 	}
 
-	if t == generate.ConvertStringQueryParam {
-		setVal.Elem().SetString(paramString)
-		return nil
+	if !converter.IsSlice {
+		return parseParam(converter, setVal, values[0])
 	}
 
-	paramInt, err := strconv.Atoi(paramString)
-	if err != nil {
+	list := reflect.MakeSlice(converter.ElemType(), 0, len(values))
+	for _, value := range values {
+		item := reflect.New(converter.ValueType())
+		if err := parseParam(converter, item, value); err != nil {
+			return err
+		}
+		list = reflect.Append(list, item.Elem())
+	}
+	setVal.Elem().Set(list)
+
+	return nil
+}
+
+// parseParam parses a single parameter value into what setVal points to
+func parseParam(converter *generate.Converter, setVal reflect.Value, paramString string) error {
+	location := converter.ConversionType.ParamLocation()
+
+	invalid := func(expected string) error {
 		return Errorf(
 			http.StatusBadRequest,
-			"query param '%s' expected to be integer value",
+			"%s param '%s' expected to be %s value",
+			location,
 			converter.Name,
+			expected,
 		)
 	}
-	setVal.Elem().SetInt(int64(paramInt))
+
+	elem := setVal.Elem()
+
+	switch converter.ParamKind {
+	case generate.ParamString:
+		elem.SetString(paramString)
+	case generate.ParamInt:
+		paramInt, err := strconv.ParseInt(paramString, 10, elem.Type().Bits())
+		if err != nil {
+			return invalid("integer")
+		}
+		elem.SetInt(paramInt)
+	case generate.ParamUint:
+		paramUint, err := strconv.ParseUint(paramString, 10, elem.Type().Bits())
+		if err != nil {
+			return invalid("non-negative integer")
+		}
+		elem.SetUint(paramUint)
+	case generate.ParamFloat:
+		paramFloat, err := strconv.ParseFloat(paramString, elem.Type().Bits())
+		if err != nil {
+			return invalid("number")
+		}
+		elem.SetFloat(paramFloat)
+	case generate.ParamBool:
+		paramBool, err := strconv.ParseBool(paramString)
+		if err != nil {
+			return invalid("boolean")
+		}
+		elem.SetBool(paramBool)
+	case generate.ParamTime:
+		paramTime, err := time.Parse(time.RFC3339, paramString)
+		if err != nil {
+			return invalid("RFC 3339 time")
+		}
+		elem.Set(reflect.ValueOf(paramTime).Convert(elem.Type()))
+	case generate.ParamDuration:
+		paramDuration, err := time.ParseDuration(paramString)
+		if err != nil {
+			return invalid("duration")
+		}
+		if elem.Kind() == reflect.Struct {
+			elem.Set(reflect.ValueOf(Duration{paramDuration}).Convert(elem.Type()))
+		} else {
+			elem.SetInt(int64(paramDuration))
+		}
+	case generate.ParamText:
+		err := setVal.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(paramString))
+		if err != nil {
+			return Errorf(
+				http.StatusBadRequest,
+				"%s param '%s' is invalid: %s",
+				location,
+				converter.Name,
+				err.Error(),
+			)
+		}
+	}
 
 	return nil
 }
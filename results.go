@@ -0,0 +1,83 @@
+package plumbus
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/jargv/plumbus/generate"
+)
+
+// ResponseCoder can be implemented by a response body or another return value
+// to choose the status of a successful response
+type ResponseCoder generate.ResponseCoder
+
+// Status is a return value which sets the status of a successful response,
+// e.g. `func() (*Thing, plumbus.Status)`
+type Status int
+
+func (s Status) ResponseCode() int {
+	return int(s)
+}
+
+func (s Status) ToResponse(res http.ResponseWriter) error {
+	return nil
+}
+
+// Created is a return value which responds 201 Created, with a Location
+// header if one is given
+type Created struct {
+	Location string
+}
+
+func (c Created) ResponseCode() int {
+	return http.StatusCreated
+}
+
+func (c Created) ToResponse(res http.ResponseWriter) error {
+	if c.Location != "" {
+		res.Header().Set("Location", c.Location)
+	}
+	return nil
+}
+
+// NoContent is a return value which responds 204 No Content. Any response
+// body is left out.
+type NoContent struct{}
+
+func (NoContent) ResponseCode() int {
+	return http.StatusNoContent
+}
+
+func (NoContent) ToResponse(res http.ResponseWriter) error {
+	return nil
+}
+
+// ResponseCodeOf finds the status asked for by the first of a handler's
+// return values that implements ResponseCoder, or 0 if none do
+func ResponseCodeOf(results ...interface{}) int {
+	for _, result := range results {
+		coder, ok := result.(ResponseCoder)
+		if !ok {
+			continue
+		}
+		if val := reflect.ValueOf(result); val.Kind() == reflect.Ptr && val.IsNil() {
+			continue
+		}
+		if code := coder.ResponseCode(); code != 0 {
+			return code
+		}
+	}
+	return 0
+}
+
+// WriteResponseCode sends the status asked for by a handler without a response
+// body, if it asked for one
+func WriteResponseCode(res http.ResponseWriter, code int) {
+	if code != 0 {
+		res.WriteHeader(code)
+	}
+}
+
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,301 @@
+package plumbus
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema describing a request or response body. Named
+// struct types are collected as definitions and referred to with "$ref",
+// which lets recursive types be described.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// enumer can be implemented by a type to list the values it allows
+type enumer interface {
+	Enum() []interface{}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type schemaCollector struct {
+	refFormat string
+	defs      map[string]*Schema
+	types     map[string]reflect.Type
+}
+
+// newSchemaCollector creates a collector whose references are made by
+// formatting the definition name with refFormat
+func newSchemaCollector(refFormat string) *schemaCollector {
+	return &schemaCollector{
+		refFormat: refFormat,
+		defs:      map[string]*Schema{},
+		types:     map[string]reflect.Type{},
+	}
+}
+
+func (sc *schemaCollector) ref(name string) *Schema {
+	return &Schema{Ref: fmt.Sprintf(sc.refFormat, name)}
+}
+
+func (sc *schemaCollector) schema(typ reflect.Type) *Schema {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	s := sc.typeSchema(typ)
+
+	if s.Ref == "" {
+		if enum, ok := reflect.New(typ).Interface().(enumer); ok {
+			s.Enum = enum.Enum()
+		}
+	}
+
+	return s
+}
+
+func (sc *schemaCollector) typeSchema(typ reflect.Type) *Schema {
+	ptr := reflect.PtrTo(typ)
+
+	switch {
+	case typ == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typ == fileType:
+		return &Schema{Type: "string", Format: "binary"}
+	case typ.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType):
+		//the type decides its own representation, so it could be anything
+		return &Schema{}
+	case typ.Implements(textMarshalerType) || ptr.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			//encoding/json sends []byte as a base64 string
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: sc.schema(typ.Elem())}
+	case reflect.Array:
+		length := typ.Len()
+		return &Schema{
+			Type:     "array",
+			Items:    sc.schema(typ.Elem()),
+			MinItems: &length,
+			MaxItems: &length,
+		}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sc.schema(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return sc.structSchema(typ)
+		}
+		name := typeName(typ)
+		if _, exists := sc.defs[name]; !exists {
+			//reserve the name first so recursive types terminate
+			def := &Schema{}
+			sc.defs[name] = def
+			sc.types[name] = typ
+			*def = *sc.structSchema(typ)
+			if doc, ok := reflect.New(typ).Interface().(documenter); ok {
+				def.Description = cleanupText(doc.Documentation())
+			}
+		}
+		return sc.ref(name)
+	}
+
+	//interfaces, funcs, etc. could be anything
+	return &Schema{}
+}
+
+func (sc *schemaCollector) structSchema(typ reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+	sc.addFields(s, typ)
+	return s
+}
+
+// addFields adds the fields of the struct type to the schema, following the
+// rules of encoding/json for names, embedded structs, and tag options. Fields
+// of embedded structs never replace the fields already added.
+func (sc *schemaCollector) addFields(s *Schema, typ reflect.Type) {
+	embedded := []reflect.Type{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		name := options[0]
+		options = options[1:]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded = append(embedded, fieldType)
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if _, exists := s.Properties[name]; exists {
+			continue
+		}
+
+		prop := sc.schema(field.Type)
+		if hasOption(options, "string") && prop.Ref == "" {
+			switch prop.Type {
+			case "boolean", "integer", "number", "string":
+				prop = &Schema{Type: "string", Description: prop.Description, Enum: prop.Enum}
+			}
+		}
+		required := !hasOption(options, "omitempty") && field.Type.Kind() != reflect.Ptr
+		if tag := field.Tag.Get("validate"); tag != "" {
+			var validateRequired bool
+			prop, validateRequired = applyValidation(prop, tag)
+			required = required || validateRequired
+		}
+		s.Properties[name] = prop
+
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	for _, typ := range embedded {
+		sc.addFields(s, typ)
+	}
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidation adds the rules of a field's `validate` tag to its schema,
+// and reports whether the field is required by them. Rules the schema can't
+// express are left out.
+func applyValidation(prop *Schema, tag string) (*Schema, bool) {
+	rules, err := parseValidateTag(tag)
+	if err != nil {
+		return prop, false
+	}
+
+	if prop.Ref != "" {
+		//a definition is shared, so the rules can't be added to it
+		for _, rule := range rules {
+			if rule.name == "required" {
+				return prop, true
+			}
+		}
+		return prop, false
+	}
+
+	validated := *prop
+	required := false
+	for _, rule := range rules {
+		bound, _ := strconv.ParseFloat(rule.arg, 64)
+		length := int(bound)
+		switch rule.name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			isMin := rule.name != "max"
+			isMax := rule.name != "min"
+			switch validated.Type {
+			case "integer", "number":
+				if isMin {
+					validated.Minimum = &bound
+				}
+				if isMax {
+					validated.Maximum = &bound
+				}
+			case "string":
+				if isMin {
+					validated.MinLength = &length
+				}
+				if isMax {
+					validated.MaxLength = &length
+				}
+			case "array":
+				if isMin {
+					validated.MinItems = &length
+				}
+				if isMax {
+					validated.MaxItems = &length
+				}
+			}
+		case "oneof":
+			validated.Enum = nil
+			for _, value := range strings.Fields(rule.arg) {
+				if validated.Type == "integer" || validated.Type == "number" {
+					if n, err := strconv.ParseFloat(value, 64); err == nil {
+						validated.Enum = append(validated.Enum, n)
+						continue
+					}
+				}
+				validated.Enum = append(validated.Enum, value)
+			}
+		case "regex":
+			validated.Pattern = rule.arg
+		}
+	}
+	return &validated, required
+}
@@ -0,0 +1,109 @@
+package plumbus
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+// StreamReader copies r to the response as it's read, flushing after each
+// chunk so the client sees it right away. If r is also an io.Closer it's
+// closed afterwards. A code other than 0 is sent as the response's status,
+// and a nil reader sends no body at all.
+func StreamReader(res http.ResponseWriter, req *http.Request, code int, r io.Reader) {
+	if val := reflect.ValueOf(r); !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		WriteResponseCode(res, code)
+		return
+	}
+
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	StartStream(res, code, "application/octet-stream")
+
+	flusher, _ := res.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := res.Write(buf[:n]); err != nil {
+				logStreamError(req, err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			logStreamError(req, err)
+			return
+		}
+	}
+}
+
+// StreamChannel sends each value received from ch, which must be a channel, as
+// a line of JSON. It stops when ch is closed or the client goes away.
+func StreamChannel(res http.ResponseWriter, req *http.Request, code int, ch interface{}) {
+	StartStream(res, code, "application/x-ndjson")
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(req.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)},
+	}
+	for {
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 0 || !ok {
+			return
+		}
+		if err := StreamValue(res, req, value.Interface()); err != nil {
+			return
+		}
+	}
+}
+
+// StartStream sends the headers of a streamed response, using the content
+// type given unless the handler already set one
+func StartStream(res http.ResponseWriter, code int, contentType string) {
+	if res.Header().Get("Content-Type") == "" {
+		res.Header().Set("Content-Type", contentType)
+	}
+	if code == 0 {
+		code = http.StatusOK
+	}
+	res.WriteHeader(code)
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// StreamValue sends v as a line of JSON and flushes it to the client. Once
+// it returns an error the stream should be stopped.
+func StreamValue(res http.ResponseWriter, req *http.Request, v interface{}) error {
+	if err := json.NewEncoder(res).Encode(v); err != nil {
+		logStreamError(req, err)
+		return err
+	}
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func logStreamError(req *http.Request, err error) {
+	if req.Context().Err() != nil {
+		//the client went away, there's nobody to tell
+		return
+	}
+	log.Printf(
+		"error streaming response: %s %s: %v",
+		req.Method,
+		req.URL.Path,
+		err,
+	)
+}
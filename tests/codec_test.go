@@ -0,0 +1,177 @@
+package plumbus
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+type lineCodec struct{}
+
+func (lineCodec) Decode(r io.Reader, v interface{}) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(body)), " ", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected a count and a message")
+	}
+	echo := v.(**EchoBody)
+	*echo = &EchoBody{Message: parts[1]}
+	_, err = fmt.Sscan(parts[0], &(*echo).Count)
+	return err
+}
+
+func (lineCodec) Encode(w io.Writer, v interface{}) error {
+	echo := v.(*EchoBody)
+	_, err := fmt.Fprintf(w, "%d %s", echo.Count, echo.Message)
+	return err
+}
+
+func TestContentNegotiation(t *testing.T) {
+	RegisterCodec("application/x-echo-line", lineCodec{})
+
+	server := httptest.NewServer(HandlerFunc(EchoHandler))
+	defer server.Close()
+
+	cases := []struct {
+		contentType string
+		accept      string
+		body        string
+		status      int
+		resultType  string
+		result      string
+	}{
+		{"", "", `{"message":"hi","count":1}`, http.StatusOK, "application/json", `{"message":"hi","count":1}`},
+		{"application/json; charset=utf-8", "application/*", `{"message":"hi","count":1}`, http.StatusOK, "application/json", `{"message":"hi","count":1}`},
+		{"application/xml", "text/xml", `<EchoBody><message>hi</message><count>2</count></EchoBody>`, http.StatusOK, "text/xml", `<EchoBody><message>hi</message><count>2</count></EchoBody>`},
+		{"application/x-www-form-urlencoded", "application/xml;q=0.5, application/json", "message=hi&count=3", http.StatusOK, "application/json", `{"message":"hi","count":3}`},
+		{"application/x-echo-line", "application/x-echo-line", "4 hi there", http.StatusOK, "application/x-echo-line", "4 hi there"},
+		{"application/x-www-form-urlencoded", "", "count=many", http.StatusBadRequest, "", ""},
+		{"text/csv", "", "hi,1", http.StatusUnsupportedMediaType, "", ""},
+		{"", "text/csv", `{"message":"hi","count":1}`, http.StatusNotAcceptable, "", ""},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", `{"message":"hi","count":1}`, http.StatusOK, "application/xml", `<EchoBody><message>hi</message><count>1</count></EchoBody>`},
+		{"", "application/xml, */*;q=0.1", `{"message":"hi","count":1}`, http.StatusOK, "application/xml", `<EchoBody><message>hi</message><count>1</count></EchoBody>`},
+		{"", "application/xml, application/*;q=0.5", `{"message":"hi","count":1}`, http.StatusOK, "application/xml", `<EchoBody><message>hi</message><count>1</count></EchoBody>`},
+		{"", "application/xml, */*", `{"message":"hi","count":1}`, http.StatusOK, "application/json", `{"message":"hi","count":1}`},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("POST", server.URL, strings.NewReader(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+
+		if resp.StatusCode != c.status {
+			t.Fatalf(`%s -> %s: resp.StatusCode != %d, resp.StatusCode == "%v"`, c.contentType, c.accept, c.status, resp.StatusCode)
+		}
+
+		if c.status != http.StatusOK {
+			continue
+		}
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != c.resultType {
+			t.Fatalf(`%s -> %s: Content-Type != %q, Content-Type == %q`, c.contentType, c.accept, c.resultType, contentType)
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		if result := strings.TrimSpace(string(body)); result != c.result {
+			t.Fatalf(`%s -> %s: body != %q, body == %q`, c.contentType, c.accept, c.result, result)
+		}
+	}
+}
+
+func TestBrowserAcceptGetsJSON(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/spec", func() interface{} {
+		return mux.Documentation()
+	})
+	mux.Handle("/map", func() map[string]string {
+		return map[string]string{"a": "b"}
+	})
+
+	for _, path := range []string{"/spec", "/map"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Fatalf(`%s: res.Code != http.StatusOK, res.Code == %d: %s`, path, res.Code, res.Body.String())
+		}
+		if contentType := res.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Fatalf(`%s: contentType != "application/json", contentType == %q`, path, contentType)
+		}
+	}
+}
+
+func TestNotAcceptableSkipsHandler(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/echo", CreatedHandler)
+
+	CreatedCalled = false
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader(`{"message":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "image/png")
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotAcceptable {
+		t.Fatalf(`res.Code != http.StatusNotAcceptable, res.Code == %d`, res.Code)
+	}
+	if CreatedCalled {
+		t.Fatalf(`CreatedCalled != false, the handler ran before negotiation`)
+	}
+	if location := res.Header().Get("Location"); location != "" {
+		t.Fatalf(`location != "", location == %q`, location)
+	}
+}
+
+func TestUnencodableFallsBack(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/map", func() map[string]int {
+		return map[string]int{"a": 1}
+	})
+
+	cases := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"application/xml, application/json;q=0.5", http.StatusOK, "application/json"},
+		{"application/xml", http.StatusNotAcceptable, ""},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/map", nil)
+		req.Header.Set("Accept", c.accept)
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+
+		if res.Code != c.status {
+			t.Fatalf(`%s: res.Code != %d, res.Code == %d: %s`, c.accept, c.status, res.Code, res.Body.String())
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if contentType := res.Header().Get("Content-Type"); contentType != c.contentType {
+			t.Fatalf(`%s: contentType != %q, contentType == %q`, c.accept, c.contentType, contentType)
+		}
+	}
+}
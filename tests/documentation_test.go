@@ -0,0 +1,115 @@
+package plumbus
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+type schemaColor string
+
+func (schemaColor) Enum() []interface{} {
+	return []interface{}{"red", "green"}
+}
+
+type schemaRawMessage struct{}
+
+func (schemaRawMessage) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+type schemaBase struct {
+	ID      int `json:"id"`
+	Ignored int `json:"-"`
+}
+
+type schemaNode struct {
+	schemaBase
+	Name     string            `json:"name"`
+	Color    schemaColor       `json:"color,omitempty"`
+	Count    int64             `json:"count,string"`
+	Parent   *schemaNode       `json:"parent"`
+	Children []*schemaNode     `json:"children"`
+	Created  time.Time         `json:"created"`
+	Raw      schemaRawMessage  `json:"raw"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	internal int
+}
+
+func TestDocumentationSchema(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/node", func(n *schemaNode) *schemaNode {
+		return n
+	})
+
+	docs := mux.Documentation()
+
+	if _, err := json.Marshal(docs); err != nil {
+		t.Fatalf("marshaling documentation: %v", err)
+	}
+
+	node, ok := docs.Types["schemaNode"]
+	if !ok || node.Schema == nil {
+		t.Fatalf("expected a schema for schemaNode, got %#v", node)
+	}
+
+	props := node.Schema.Properties
+	expectedProps := []string{"id", "name", "color", "count", "parent", "children", "created", "raw", "labels"}
+	if len(props) != len(expectedProps) {
+		t.Fatalf("expected properties %v, got %v", expectedProps, props)
+	}
+	for _, name := range expectedProps {
+		if _, ok := props[name]; !ok {
+			t.Fatalf("expected property %q, got %v", name, props)
+		}
+	}
+
+	if ref := props["parent"].Ref; ref != "#/types/schemaNode/schema" {
+		t.Fatalf(`ref != "#/types/schemaNode/schema", ref == %q`, ref)
+	}
+	if ref := props["children"].Items.Ref; ref != "#/types/schemaNode/schema" {
+		t.Fatalf(`ref != "#/types/schemaNode/schema", ref == %q`, ref)
+	}
+	if props["count"].Type != "string" {
+		t.Fatalf(`props["count"].Type != "string", props["count"].Type == %q`, props["count"].Type)
+	}
+	if props["created"].Format != "date-time" {
+		t.Fatalf(`props["created"].Format != "date-time", props["created"].Format == %q`, props["created"].Format)
+	}
+	if props["raw"].Type != "" {
+		t.Fatalf(`props["raw"].Type != "", props["raw"].Type == %q`, props["raw"].Type)
+	}
+	if !reflect.DeepEqual(props["color"].Enum, []interface{}{"red", "green"}) {
+		t.Fatalf(`unexpected enum for color: %v`, props["color"].Enum)
+	}
+
+	expectedRequired := []string{"name", "count", "children", "created", "raw", "id"}
+	if !reflect.DeepEqual(node.Schema.Required, expectedRequired) {
+		t.Fatalf("expected required %v, got %v", expectedRequired, node.Schema.Required)
+	}
+}
+
+func TestDocumentationParamTypes(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/kinds", QueryParamKindsHandler)
+
+	docs := mux.Documentation()
+	params := docs.Endpoints[0].Params
+
+	expected := map[string]ParamInfo{
+		"limit":   {Type: "integer", Required: true},
+		"offset":  {Type: "integer", Required: true},
+		"ratio":   {Type: "number", Required: true},
+		"active":  {Type: "boolean", Required: false},
+		"since":   {Type: "string", Required: true},
+		"timeout": {Type: "string", Required: false},
+	}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expected params %#v, got %#v", expected, params)
+	}
+}
@@ -0,0 +1,69 @@
+package plumbus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestErrorHandler(t *testing.T) {
+	var sources []ErrorSource
+	mux := NewServeMux()
+	mux.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error, source ErrorSource) {
+		sources = append(sources, source)
+		if source == SourceHandler {
+			res.WriteHeader(http.StatusTeapot)
+			return
+		}
+		HandleResponseError(res, req, err)
+	}
+	mux.Handle("/error", ReturnErrorHandler)
+	mux.Handle("/body", ByMethod{POST: RequestBodyHandler})
+	mux.Handle("/user/:userId/friends", ParamsStructHandler)
+	mux.Handle("/signup", SignupHandler)
+	mux.Handle("/struct", ReturnStructHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	do := func(method, path, body, accept string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		method, path, body, accept string
+		source                     ErrorSource
+		code                       int
+	}{
+		{"GET", "/nowhere", "", "", SourceRouting, http.StatusNotFound},
+		{"GET", "/body", "", "", SourceRouting, http.StatusMethodNotAllowed},
+		{"POST", "/body", "{", "", SourceDecode, http.StatusBadRequest},
+		{"GET", "/user/7/friends", "", "", SourceParam, http.StatusBadRequest},
+		{"POST", "/signup", "{}", "", SourceValidation, http.StatusUnprocessableEntity},
+		{"GET", "/error", "", "", SourceHandler, http.StatusTeapot},
+		{"GET", "/struct", "", "image/png", SourceEncode, http.StatusNotAcceptable},
+	}
+
+	for _, test := range tests {
+		sources = nil
+		resp := do(test.method, test.path, test.body, test.accept)
+		if resp.StatusCode != test.code {
+			t.Fatalf(`%s %s: resp.StatusCode != %d, resp.StatusCode == %d`, test.method, test.path, test.code, resp.StatusCode)
+		}
+		if len(sources) != 1 || sources[0] != test.source {
+			t.Fatalf(`%s %s: expected source %s, got %v`, test.method, test.path, test.source, sources)
+		}
+	}
+}
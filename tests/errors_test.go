@@ -0,0 +1,67 @@
+package plumbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestWrappedHTTPError(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(WrappedErrorHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf(`resp.StatusCode != http.StatusNotFound, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	body := map[string]interface{}{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if !reflect.DeepEqual(body, map[string]interface{}{"error": "loading user: no such user"}) {
+		t.Fatalf(`unexpected error body %v`, body)
+	}
+}
+
+func TestWrapErrorUnwraps(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := WrapError(http.StatusConflict, sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("expected WrapError's result to wrap %v", sentinel)
+	}
+}
+
+func TestRegisterErrorCode(t *testing.T) {
+	RegisterErrorCode(ErrSentinel, http.StatusGone)
+	RegisterErrorCode(context.DeadlineExceeded, http.StatusGatewayTimeout)
+
+	handler := HandlerFunc(SentinelErrorHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusGone {
+		t.Fatalf(`res.Code != http.StatusGone, res.Code == %d`, res.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ErrSentinel, true))
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusGatewayTimeout {
+		t.Fatalf(`res.Code != http.StatusGatewayTimeout, res.Code == %d`, res.Code)
+	}
+
+	httperr, ok := AsHTTPError(errors.New("unregistered"))
+	if ok {
+		t.Fatalf("expected no HTTPError for an unregistered error, got %v", httperr)
+	}
+}
@@ -0,0 +1,86 @@
+package plumbus
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestEventStream(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(EventsHandler))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Last-Event-ID", "7")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf(`contentType != "text/event-stream", contentType == %q`, contentType)
+	}
+
+	if EventsLastId != "7" {
+		t.Fatalf(`EventsLastId != "7", EventsLastId == %q`, EventsLastId)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	expected := "id: 1\nevent: greeting\nretry: 2000\ndata: hello\ndata: world\n\n" +
+		"id: 2\ndata: {\"message\":\"hi\",\"count\":2}\n\n"
+	if string(body) != expected {
+		t.Fatalf("expected body %q, got %q", expected, body)
+	}
+}
+
+func TestEventStreamHeartbeat(t *testing.T) {
+	defer func(heartbeat time.Duration) {
+		EventHeartbeat = heartbeat
+	}(EventHeartbeat)
+	EventHeartbeat = 10 * time.Millisecond
+
+	mux := NewServeMux()
+	mux.Handle("/ticks", TickEventsHandler)
+	server := httptest.NewServer(mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", server.URL+"/ticks", nil)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	lines := []string{}
+	for len(lines) < 4 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	expected := []string{"event: tick", `data: {"count":1}`, "", ": heartbeat"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected lines %q, got %q", expected, lines)
+	}
+
+	cancel()
+	resp.Body.Close()
+
+	//blocks until the handler stops streaming
+	server.Close()
+
+	e := mux.Documentation().Endpoints[0]
+	if e.ResponseStream != "text/event-stream" || e.ResponseBody != "Tick" {
+		t.Fatalf("expected an event stream of Tick, got %#v", e)
+	}
+}
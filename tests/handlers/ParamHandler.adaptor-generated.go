@@ -7,17 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
 	"strconv"
-	"fmt"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
 var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -42,14 +44,23 @@ func init(){
 			
 		))
 
+		
+			
+		
+			
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
 			
 			
 			
 				var arg0 ParamType
 					
 					if err := arg0.FromRequest(req); err != nil {
-						plumbus.HandleResponseError(res, req, err)
+						plumbus.HandleError(res, req, err, plumbus.SourceParam)
 						return
 					}
 				
@@ -59,7 +70,7 @@ func init(){
 						arg1 = new(ParamType)
 					
 					if err := arg1.FromRequest(req); err != nil {
-						plumbus.HandleResponseError(res, req, err)
+						plumbus.HandleError(res, req, err, plumbus.SourceParam)
 						return
 					}
 				
@@ -80,6 +91,16 @@ func init(){
 			
 
 			
+
+			code := plumbus.ResponseCodeOf(
+				
+			)
+
+			
+				plumbus.WriteResponseCode(res, code)
+			
 		})
 	})
 }
+
+
@@ -7,17 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
 	"strconv"
-	"fmt"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
 var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -38,14 +40,30 @@ func init(){
 			
 		))
 
+		
+			
+				if err := plumbus.CheckValidateTags(reflect.TypeOf((**RequestBodyBody)(nil)).Elem()); err != nil {
+					panic(err)
+				}
+			
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+				defer plumbus.CleanupMultipart(req)
+			
 			
 			
 			
 				var arg0 *RequestBodyBody
-					if err := json.NewDecoder(req.Body).Decode(&arg0); err != nil {
-						msg := fmt.Sprintf("{\"error\": \"decoding json: %s\"}", err.Error())
-						http.Error(res, msg, http.StatusBadRequest)
+					if err := plumbus.DecodeBody(req, &arg0); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceDecode)
+						return
+					}
+					if err := plumbus.Validate(arg0); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceValidation)
 						return
 					}
 				
@@ -64,6 +82,16 @@ func init(){
 			
 
 			
+
+			code := plumbus.ResponseCodeOf(
+				
+			)
+
+			
+				plumbus.WriteResponseCode(res, code)
+			
 		})
 	})
 }
+
+
@@ -7,17 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
 	"strconv"
-	"fmt"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
 var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -42,58 +44,100 @@ func init(){
 			
 		))
 
+		
+			
+		
+			
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
 			
 				queryParams := req.URL.Query()
 			
 			
 			
+			
+			
 				var arg0 foodQueryParam
-				  
-						if l, sent := queryParams["food"]; sent && len(l) > 0{
-							arg0 = foodQueryParam(l[0])
-						} else {
-							plumbus.HandleResponseError(
-								res, req,
-								plumbus.Errorf(
-									http.StatusBadRequest,
-									"missing required query parameter 'food'",
-								),
-							)
-							return
-						}
 					
+	{
+		
+			values := queryParams["food"]
+		
+		
+		
+		var paramErr error
+		
+			if len(values) == 0 {
+				paramErr = plumbus.Errorf(
+					http.StatusBadRequest,
+					"missing required query parameter 'food'",
+				)
+			} else {
+		
+			
+				value := values[0]
+				
+	
+		parsed := foodQueryParam(value)
+	
+
+				arg0 = parsed
+			
+		}
+		if paramErr != nil {
+			
+				plumbus.HandleError(res, req, paramErr, plumbus.SourceParam)
+				return
+			
+		}
+	}
+
 				
 			
 				var arg1 amountQueryParam
-				  {
-						
-							l, sent := queryParams["amount"]
-							if !sent || len(l) == 0 {
-								plumbus.HandleResponseError(
-									res, req,
-									plumbus.Errorf(
-										http.StatusBadRequest,
-										"missing required query parameter 'amount'",
-									),
-								)
-								return
-							}
-							queryInt, err := strconv.Atoi(l[0])
-							if err != nil {
-								plumbus.HandleResponseError(
-									res, req,
-									plumbus.Errorf(
-										http.StatusBadRequest,
-										"query param 'amount' expected to be integer value",
-									),
-								)
-								return
-							}
-
-							arg1 = amountQueryParam(queryInt)
-						
-					}
+					
+	{
+		
+			values := queryParams["amount"]
+		
+		
+		
+		var paramErr error
+		
+			if len(values) == 0 {
+				paramErr = plumbus.Errorf(
+					http.StatusBadRequest,
+					"missing required query parameter 'amount'",
+				)
+			} else {
+		
+			
+				value := values[0]
+				
+	
+		parsedInt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			paramErr = plumbus.Errorf(
+				http.StatusBadRequest,
+				"query param 'amount' expected to be integer value",
+			)
+		}
+		parsed := amountQueryParam(parsedInt)
+	
+
+				arg1 = parsed
+			
+		}
+		if paramErr != nil {
+			
+				plumbus.HandleError(res, req, paramErr, plumbus.SourceParam)
+				return
+			
+		}
+	}
+
 				
 			
 
@@ -112,6 +156,16 @@ func init(){
 			
 
 			
+
+			code := plumbus.ResponseCodeOf(
+				
+			)
+
+			
+				plumbus.WriteResponseCode(res, code)
+			
 		})
 	})
 }
+
+
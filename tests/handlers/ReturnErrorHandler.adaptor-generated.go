@@ -7,17 +7,19 @@ import (
 	"github.com/jargv/plumbus"
 	"net/http"
 	"reflect"
-	"encoding/json"
 	"strconv"
-	"fmt"
 	"log"
+	"context"
+	"io"
+	"time"
 )
 
 // avoid unused import errors
-var _ json.Delim
 var _ log.Logger
-var _ fmt.Formatter
 var _ strconv.NumError
+var _ context.Context
+var _ io.Reader
+var _ time.Duration
 
 func init(){
 	var dummy func(
@@ -42,7 +44,18 @@ func init(){
 			
 		))
 
+		
+
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request){
+			defer plumbus.RecoverPanic(res, req)
+			
+			
+			
+				responseCodec, err := plumbus.NegotiateCodec(req)
+				if err != nil {
+					plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+					return
+				}
 			
 			
 			
@@ -61,25 +74,39 @@ func init(){
 			
 			
 				if result1 != nil {
-					plumbus.HandleResponseError(res, req, result1.(error))
+					plumbus.HandleError(res, req, result1.(error), plumbus.SourceHandler)
 					return
 				}
 			
 
 			
 				
+			
+				
+			
+
+			code := plumbus.ResponseCodeOf(
+				
+					
+						result0,
 					
-						{
-							if err := json.NewEncoder(res).Encode(result0); err != nil {
-								plumbus.HandleResponseError(res, req, err)
-								return
-							}
-						}
+				
 					
 				
+			)
+
 			
 				
+				
+				
+					if err := plumbus.EncodeBody(res, code, responseCodec, result0); err != nil {
+						plumbus.HandleError(res, req, err, plumbus.SourceEncode)
+						return
+					}
+				
 			
 		})
 	})
 }
+
+
@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	. "github.com/jargv/plumbus"
 )
@@ -60,7 +68,8 @@ func RequestMethodHandler() string {
 type userId string
 
 func (ui *userId) FromRequest(req *http.Request) error {
-	*ui = userId(req.URL.Query().Get("userId"))
+	id, _ := PathParam(req, "userId")
+	*ui = userId(id)
 	return nil
 }
 
@@ -97,3 +106,414 @@ func OptionalRequestParamHandler(amount *amountQueryParam, food *foodQueryParam)
 		OptionalRequestParamAmount = int(*amount)
 	}
 }
+
+var ContextHandlerHadContext bool
+
+//go:generate plumbus ContextHandler
+func ContextHandler(ctx context.Context, food foodQueryParam) {
+	ContextHandlerHadContext = ctx != nil && ctx.Err() == nil
+}
+
+var RawRequestHeader string
+
+//go:generate plumbus RawRequestHandler
+func RawRequestHandler(res http.ResponseWriter, food foodQueryParam, req *http.Request) ReturnStructResult {
+	RawRequestHeader = req.Header.Get("X-Food")
+	res.Header().Set("X-Food", string(food))
+	return ReturnStructResult{
+		Message: "Victory!",
+	}
+}
+
+type userIdPathParam int64
+type userIdQueryParam string
+
+type orderIdPathParam [2]byte
+
+func (o *orderIdPathParam) UnmarshalText(text []byte) error {
+	if len(text) != len(o) {
+		return fmt.Errorf("expected %d characters", len(o))
+	}
+	copy(o[:], text)
+	return nil
+}
+
+var (
+	TypedPathParamUserId  int64
+	TypedPathParamOrderId string
+	TypedPathParamQuery   string
+)
+
+//go:generate plumbus TypedPathParamHandler
+func TypedPathParamHandler(id userIdPathParam, order orderIdPathParam, query *userIdQueryParam) {
+	TypedPathParamUserId = int64(id)
+	TypedPathParamOrderId = string(order[:])
+	TypedPathParamQuery = ""
+	if query != nil {
+		TypedPathParamQuery = string(*query)
+	}
+}
+
+type limitQueryParam int64
+type offsetQueryParam uint16
+type ratioQueryParam float64
+type activeQueryParam bool
+type sinceQueryParam time.Time
+type timeoutQueryParam Duration
+
+var (
+	QueryParamKindsLimit   int64
+	QueryParamKindsOffset  uint16
+	QueryParamKindsRatio   float64
+	QueryParamKindsActive  bool
+	QueryParamKindsSince   time.Time
+	QueryParamKindsTimeout time.Duration
+)
+
+//go:generate plumbus QueryParamKindsHandler
+func QueryParamKindsHandler(
+	limit limitQueryParam,
+	offset offsetQueryParam,
+	ratio ratioQueryParam,
+	active *activeQueryParam,
+	since sinceQueryParam,
+	timeout *timeoutQueryParam,
+) {
+	QueryParamKindsLimit = int64(limit)
+	QueryParamKindsOffset = uint16(offset)
+	QueryParamKindsRatio = float64(ratio)
+	QueryParamKindsActive = active != nil && bool(*active)
+	QueryParamKindsSince = time.Time(since)
+	QueryParamKindsTimeout = 0
+	if timeout != nil {
+		QueryParamKindsTimeout = timeout.Duration
+	}
+}
+
+type tagQueryParam []string
+type idQueryParam []int
+
+var (
+	SliceQueryParamTags []string
+	SliceQueryParamIds  []int
+)
+
+//go:generate plumbus SliceQueryParamHandler
+func SliceQueryParamHandler(tags tagQueryParam, ids *idQueryParam) {
+	SliceQueryParamTags = tags
+	SliceQueryParamIds = nil
+	if ids != nil {
+		SliceQueryParamIds = *ids
+	}
+}
+
+type ListUsersParams struct {
+	UserId  int64    `plumbus:"path=userId"`
+	Limit   int      `plumbus:"query=limit,default=20"`
+	Tags    []string `plumbus:"query=tag"`
+	Tenant  string   `plumbus:"header=X-Tenant"`
+	Session *string  `plumbus:"cookie=session"`
+	Timeout Duration `plumbus:"query=timeout,default=30s"`
+	Ignored string
+}
+
+var ListUsersResult ListUsersParams
+
+//go:generate plumbus ParamsStructHandler
+func ParamsStructHandler(params *ListUsersParams) {
+	ListUsersResult = *params
+}
+
+type xTenantIdHeader string
+type apiVersionHeader int
+type sessionCookie string
+
+var (
+	HeaderCookieTenant  string
+	HeaderCookieVersion int
+	HeaderCookieSession string
+)
+
+//go:generate plumbus HeaderCookieHandler
+func HeaderCookieHandler(tenant xTenantIdHeader, version *apiVersionHeader, session sessionCookie) {
+	HeaderCookieTenant = string(tenant)
+	HeaderCookieVersion = 0
+	if version != nil {
+		HeaderCookieVersion = int(*version)
+	}
+	HeaderCookieSession = string(session)
+}
+
+// PageHeader is a request body whose name happens to end in "Header"
+type PageHeader struct {
+	Title string `json:"title"`
+}
+
+//go:generate plumbus PageHeaderHandler
+func PageHeaderHandler(header *PageHeader) string {
+	return header.Title
+}
+
+type EchoBody struct {
+	Message string `json:"message" xml:"message"`
+	Count   int    `json:"count" xml:"count"`
+}
+
+//go:generate plumbus EchoHandler
+func EchoHandler(body *EchoBody) *EchoBody {
+	return body
+}
+
+var CreatedCalled bool
+
+//go:generate plumbus CreatedHandler
+func CreatedHandler(body *EchoBody) (*EchoBody, Created, error) {
+	CreatedCalled = true
+	return body, Created{Location: "/echo/" + body.Message}, nil
+}
+
+var NoContentCalled bool
+
+//go:generate plumbus NoContentHandler
+func NoContentHandler() (NoContent, error) {
+	NoContentCalled = true
+	return NoContent{}, nil
+}
+
+//go:generate plumbus StatusHandler
+func StatusHandler() (string, Status) {
+	return "later", http.StatusAccepted
+}
+
+type QueuedResult struct {
+	Position int
+}
+
+func (QueuedResult) ResponseCode() int {
+	return http.StatusAccepted
+}
+
+//go:generate plumbus BodyResponseCodeHandler
+func BodyResponseCodeHandler() *QueuedResult {
+	return &QueuedResult{Position: 3}
+}
+
+type closeTracker struct {
+	io.Reader
+}
+
+var ReaderClosed bool
+
+func (closeTracker) Close() error {
+	ReaderClosed = true
+	return nil
+}
+
+//go:generate plumbus ReaderHandler
+func ReaderHandler() io.ReadCloser {
+	ReaderClosed = false
+	return closeTracker{strings.NewReader("line one\nline two\n")}
+}
+
+//go:generate plumbus ChannelHandler
+func ChannelHandler() <-chan EchoBody {
+	ch := make(chan EchoBody)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= 3; i++ {
+			ch <- EchoBody{Message: "tick", Count: i}
+		}
+	}()
+	return ch
+}
+
+//go:generate plumbus EndlessChannelHandler
+func EndlessChannelHandler(ctx context.Context) <-chan int {
+	ch := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+var EventsLastId string
+
+//go:generate plumbus EventsHandler
+func EventsHandler(lastId LastEventID) <-chan Event {
+	EventsLastId = string(lastId)
+	ch := make(chan Event, 2)
+	ch <- Event{ID: "1", Event: "greeting", Data: "hello\nworld", Retry: 2 * time.Second}
+	ch <- Event{ID: "2", Data: EchoBody{Message: "hi", Count: 2}}
+	close(ch)
+	return ch
+}
+
+type Tick struct {
+	Count int `json:"count"`
+}
+
+func (t Tick) ToEvent() Event {
+	return Event{Event: "tick", Data: t}
+}
+
+//go:generate plumbus TickEventsHandler
+func TickEventsHandler(ctx context.Context) <-chan Tick {
+	ch := make(chan Tick)
+	go func() {
+		defer close(ch)
+		select {
+		case ch <- Tick{Count: 1}:
+		case <-ctx.Done():
+			return
+		}
+		//idle until the client goes away
+		<-ctx.Done()
+	}()
+	return ch
+}
+
+var UploadTempFile string
+
+//go:generate plumbus UploadHandler
+func UploadHandler(file File) (string, error) {
+	UploadTempFile = ""
+	if osFile, ok := file.File.(*os.File); ok {
+		UploadTempFile = osFile.Name()
+	}
+	content, err := ioutil.ReadAll(file)
+	return file.Filename + ": " + string(content), err
+}
+
+//go:generate plumbus UploadsHandler
+func UploadsHandler(files []File, optional *File) []string {
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Filename)
+	}
+	return names
+}
+
+type AvatarForm struct {
+	Name   string `form:"name"`
+	Age    int    `form:"age"`
+	Avatar File   `form:"avatar" accept:"image/png,image/gif"`
+	Extras []File `form:"extras"`
+	Note   *File  `form:"note"`
+}
+
+var AvatarFormResult AvatarForm
+
+//go:generate plumbus AvatarHandler
+func AvatarHandler(form *AvatarForm) {
+	AvatarFormResult = *form
+}
+
+type Signup struct {
+	Email    string   `json:"email" validate:"required,regex=^[^@]+@[^@]+$"`
+	Age      int      `json:"age" validate:"min=13,max=130"`
+	Plan     string   `json:"plan,omitempty" validate:"oneof=free pro"`
+	Password string   `json:"password" validate:"required,min=8"`
+	Confirm  string   `json:"confirm"`
+	Tags     []string `json:"tags,omitempty" validate:"max=3"`
+	Address  *Address `json:"address,omitempty"`
+}
+
+type Address struct {
+	Zip string `json:"zip" validate:"len=5"`
+}
+
+func (s *Signup) Validate() error {
+	if s.Password != s.Confirm {
+		return fmt.Errorf("passwords don't match")
+	}
+	return nil
+}
+
+type BadValidateTag struct {
+	Name string `json:"name" validate:"requird"`
+}
+
+//go:generate plumbus BadValidateTagHandler
+func BadValidateTagHandler(body BadValidateTag) {
+}
+
+//go:generate plumbus SignupHandler
+func SignupHandler(signup Signup) string {
+	return signup.Email
+}
+
+type SearchParams struct {
+	Query string `plumbus:"query=q" validate:"required"`
+	Limit int    `plumbus:"query=limit,default=10" validate:"max=100"`
+}
+
+//go:generate plumbus SearchHandler
+func SearchHandler(params SearchParams) int {
+	return params.Limit
+}
+
+type OutOfCreditError struct {
+	Balance int
+}
+
+func (e OutOfCreditError) Error() string {
+	return fmt.Sprintf("your balance is %d", e.Balance)
+}
+
+func (e OutOfCreditError) ResponseCode() int {
+	return http.StatusForbidden
+}
+
+func (e OutOfCreditError) ProblemExtensions() map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "https://example.com/problems/out-of-credit",
+		"title":   "You do not have enough credit.",
+		"balance": e.Balance,
+	}
+}
+
+//go:generate plumbus OutOfCreditHandler
+func OutOfCreditHandler() error {
+	return OutOfCreditError{Balance: 30}
+}
+
+//go:generate plumbus InternalErrorHandler
+func InternalErrorHandler() error {
+	return fmt.Errorf("database password is hunter2")
+}
+
+//go:generate plumbus WrappedErrorHandler
+func WrappedErrorHandler() (string, error) {
+	return "", fmt.Errorf("loading user: %w", Errorf(http.StatusNotFound, "no such user"))
+}
+
+var ErrSentinel = errors.New("sentinel")
+
+//go:generate plumbus SentinelErrorHandler
+func SentinelErrorHandler(ctx context.Context) error {
+	if ctx.Value(ErrSentinel) != nil {
+		return fmt.Errorf("waiting: %w", context.DeadlineExceeded)
+	}
+	return fmt.Errorf("loading: %w", ErrSentinel)
+}
+
+//go:generate plumbus PanicHandler
+func PanicHandler(req *http.Request) string {
+	if req.URL.Query().Get("abort") != "" {
+		panic(http.ErrAbortHandler)
+	}
+	panic("something broke")
+}
+
+type filepathPathParam string
+
+//go:generate plumbus StaticFileHandler
+func StaticFileHandler(path filepathPathParam) string {
+	return "file:" + string(path)
+}
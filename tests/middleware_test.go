@@ -0,0 +1,129 @@
+package plumbus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func tagRequest(tag string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Add("X-Middleware", tag)
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "" {
+			http.Error(res, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+func middlewareMux() *ServeMux {
+	mux := NewServeMux()
+	mux.Use(tagRequest("mux"))
+	mux.Handle("/public", ReturnStructHandler)
+
+	api := mux.Group("/api", tagRequest("api"))
+	api.Handle("/struct", ReturnStructHandler)
+
+	admin := api.Group("/admin", RequireAuth)
+	admin.Handle("/struct", Route{
+		Handler:    ReturnStructHandler,
+		Middleware: []Middleware{tagRequest("route")},
+	})
+	admin.Handle("/methods", ByMethod{
+		GET: Route{Handler: ReturnStructHandler, Middleware: []Middleware{tagRequest("get")}},
+	})
+	return mux
+}
+
+func TestMiddleware(t *testing.T) {
+	mux := middlewareMux()
+
+	tests := []struct {
+		path, auth string
+		code       int
+		tags       string
+	}{
+		{"/public", "", http.StatusOK, "mux"},
+		{"/nowhere", "", http.StatusNotFound, "mux"},
+		{"/api/struct", "", http.StatusOK, "mux,api"},
+		{"/api/admin/struct", "", http.StatusUnauthorized, "mux,api"},
+		{"/api/admin/struct", "token", http.StatusOK, "mux,api,route"},
+		{"/api/admin/methods", "token", http.StatusOK, "mux,api,get"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.path, nil)
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+
+		if res.Code != test.code {
+			t.Fatalf(`%s: res.Code != %d, res.Code == %d`, test.path, test.code, res.Code)
+		}
+		tags := strings.Join(res.Header().Values("X-Middleware"), ",")
+		if tags != test.tags {
+			t.Fatalf(`%s: tags != %q, tags == %q`, test.path, test.tags, tags)
+		}
+	}
+}
+
+func TestMiddlewareBuiltOnce(t *testing.T) {
+	constructed := 0
+	counting := func(next http.Handler) http.Handler {
+		constructed++
+		return next
+	}
+
+	mux := NewServeMux()
+	mux.Use(counting)
+	mux.Group("/api", counting).Handle("/struct", ReturnStructHandler)
+	constructed = 0
+
+	for _, path := range []string{"/api/struct", "/api/struct", "/missing"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if constructed != 0 {
+		t.Fatalf(`constructed != 0, constructed == %d`, constructed)
+	}
+}
+
+func TestMiddlewareDocumentation(t *testing.T) {
+	mux := middlewareMux()
+
+	middleware := map[string][]string{}
+	for _, e := range mux.Documentation().Endpoints {
+		middleware[e.Method+e.Path] = e.Middleware
+	}
+
+	expected := map[string][]string{
+		"/public":               {"tagRequest"},
+		"/api/struct":           {"tagRequest", "tagRequest"},
+		"/api/admin/struct":     {"tagRequest", "tagRequest", "RequireAuth", "tagRequest"},
+		"GET/api/admin/methods": {"tagRequest", "tagRequest", "RequireAuth", "tagRequest"},
+	}
+	if !reflect.DeepEqual(middleware, expected) {
+		t.Fatalf("expected middleware %v, got %v", expected, middleware)
+	}
+
+	op := mux.OpenAPI("test", "1").Paths["/api/admin/struct"].Get
+	if !reflect.DeepEqual(op.Middleware, expected["/api/admin/struct"]) {
+		t.Fatalf("expected middleware %v, got %v", expected["/api/admin/struct"], op.Middleware)
+	}
+}
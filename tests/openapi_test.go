@@ -0,0 +1,92 @@
+package plumbus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestOpenAPI(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/food", RequiredRequestParamHandler)
+	mux.Handle("/user/:userId/name", PathParamsHandler)
+	mux.Handle("/message", &ByMethod{
+		GET:  ReturnStructHandler,
+		POST: RequestBodyHandler,
+	}, "send and receive messages")
+
+	spec := mux.OpenAPI("test api", "1.0")
+
+	if spec.OpenAPI != "3.1.0" {
+		t.Fatalf(`spec.OpenAPI != "3.1.0", spec.OpenAPI == %q`, spec.OpenAPI)
+	}
+
+	food := spec.Paths["/food"]
+	if food == nil || food.Get == nil {
+		t.Fatalf("expected a get operation for /food, got %#v", food)
+	}
+	if len(food.Get.Parameters) != 2 {
+		t.Fatalf(`len(food.Get.Parameters) != 2, len(food.Get.Parameters) == %d`, len(food.Get.Parameters))
+	}
+	for _, param := range food.Get.Parameters {
+		if param.In != "query" || !param.Required {
+			t.Fatalf("expected a required query param, got %#v", param)
+		}
+	}
+
+	user := spec.Paths["/user/{userId}/name"]
+	if user == nil || user.Get == nil {
+		t.Fatalf("expected a get operation for /user/{userId}/name, got %#v", user)
+	}
+	if len(user.Get.Parameters) != 1 || user.Get.Parameters[0].In != "path" {
+		t.Fatalf("expected a single path param, got %#v", user.Get.Parameters)
+	}
+
+	message := spec.Paths["/message"]
+	if message == nil || message.Get == nil || message.Post == nil {
+		t.Fatalf("expected get and post operations for /message, got %#v", message)
+	}
+	if message.Post.RequestBody == nil {
+		t.Fatalf("expected a request body for POST /message")
+	}
+	if message.Get.Description != "send and receive messages" {
+		t.Fatalf(`message.Get.Description == %q`, message.Get.Description)
+	}
+	if ref := message.Get.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/ReturnStructResult" {
+		t.Fatalf(`ref != "#/components/schemas/ReturnStructResult", ref == %q`, ref)
+	}
+	if _, ok := spec.Components.Schemas["ReturnStructResult"]; !ok {
+		t.Fatalf("expected a ReturnStructResult component schema")
+	}
+	if _, ok := message.Get.Responses["default"]; !ok {
+		t.Fatalf("expected a default error response")
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/food", RequiredRequestParamHandler)
+	mux.Handle("/openapi.json", mux.OpenAPIHandler("test api", "1.0"))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	var spec map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("decoding: %v\n", err)
+	}
+
+	paths, _ := spec["paths"].(map[string]interface{})
+	if _, ok := paths["/food"]; !ok {
+		t.Fatalf("expected /food in paths, got %#v", spec["paths"])
+	}
+}
@@ -0,0 +1,89 @@
+package plumbus
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	var reported *PanicError
+	var source ErrorSource
+	mux := NewServeMux()
+	mux.PanicReporter = func(req *http.Request, err *PanicError) {
+		reported = err
+	}
+	mux.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error, src ErrorSource) {
+		source = src
+		HandleResponseError(res, req, err)
+	}
+	mux.Handle("/panic", PanicHandler)
+
+	logged.Reset()
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", "/panic", nil))
+
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf(`res.Code != http.StatusInternalServerError, res.Code == %d`, res.Code)
+	}
+	if reported == nil || reported.Value != "something broke" {
+		t.Fatalf(`expected the panic to be reported, got %v`, reported)
+	}
+	if !bytes.Contains(reported.Stack, []byte("PanicHandler")) {
+		t.Fatalf("expected the stack to include the handler, got %s", reported.Stack)
+	}
+	if source != SourcePanic {
+		t.Fatalf(`source != SourcePanic, source == %s`, source)
+	}
+	if strings.Contains(res.Body.String(), "something broke") {
+		t.Fatalf("expected the panic to be hidden from the client, got %s", res.Body.String())
+	}
+	if logged.Len() != 0 {
+		t.Fatalf("expected a reported panic not to be logged, got %q", logged.String())
+	}
+}
+
+func TestPanicLoggedOnce(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	mux := NewServeMux()
+	mux.Handle("/panic", PanicHandler)
+	logged.Reset()
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	if count := strings.Count(logged.String(), "something broke"); count != 1 {
+		t.Fatalf(`expected the panic to be logged once, got %q`, logged.String())
+	}
+	if strings.Contains(logged.String(), "error handling request") {
+		t.Fatalf(`expected no "error handling request" line, got %q`, logged.String())
+	}
+}
+
+func TestPanicAbortHandler(t *testing.T) {
+	mux := NewServeMux()
+	mux.PanicReporter = func(req *http.Request, err *PanicError) {
+		t.Fatalf("expected http.ErrAbortHandler not to be reported, got %v", err)
+	}
+	mux.Handle("/panic", PanicHandler)
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Fatalf(`err != http.ErrAbortHandler, err == %v`, err)
+		}
+	}()
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic?abort=1", nil))
+	t.Fatalf("expected the handler to be aborted")
+}
@@ -0,0 +1,343 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestWildcardPaths(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/static/*filepath", StaticFileHandler)
+	mux.Handle("/static/index.html", func() string { return "literal" })
+	mux.Handle("/static/:name/about", func(req *http.Request) string {
+		name, _ := PathParam(req, "name")
+		return "variable:" + name
+	})
+	mux.Handle("/proxy/*rest", func(req *http.Request) string {
+		rest, _ := PathParam(req, "rest")
+		return "proxy:" + rest
+	})
+
+	tests := []struct {
+		path, expected string
+	}{
+		{"/static/css/site.css", "file:css/site.css"},
+		{"/static/index.html", "literal"},
+		{"/static/team/about", "variable:team"},
+		{"/static/team/about/more", "file:team/about/more"},
+		{"/static/", "file:"},
+		{"/proxy/a/b/c", "proxy:a/b/c"},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest("GET", test.path, nil))
+		var result string
+		json.NewDecoder(res.Body).Decode(&result)
+		if result != test.expected {
+			t.Fatalf(`%s: result != %q, result == %q`, test.path, test.expected, result)
+		}
+	}
+}
+
+func TestWildcardMustBeLast(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a wildcard before the end of a route to panic")
+		}
+	}()
+	NewServeMux().Handle("/static/*filepath/more", StaticFileHandler)
+}
+
+func TestWildcardDocumentation(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/static/*filepath", StaticFileHandler)
+	mux.Handle("/proxy/*rest", func() {})
+
+	for _, e := range mux.Documentation().Endpoints {
+		var name string
+		switch e.Path {
+		case "/static/*filepath":
+			name = "filepath"
+		case "/proxy/*rest":
+			name = "rest"
+		}
+		if param, ok := e.PathParams[name]; !ok || !param.Required {
+			t.Fatalf("expected %s to document path param %s, got %v", e.Path, name, e.PathParams)
+		}
+	}
+
+	if _, ok := mux.OpenAPI("test", "1").Paths["/proxy/{rest}"]; !ok {
+		t.Fatalf("expected an OpenAPI path for /proxy/{rest}")
+	}
+}
+
+func TestPathConstraints(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/users/me", func() string { return "me" })
+	mux.Handle("/users/:id(int)", func(req *http.Request) string {
+		id, _ := PathParam(req, "id")
+		return "id:" + id
+	})
+	mux.Handle("/users/:key(uuid)", func(req *http.Request) string {
+		key, _ := PathParam(req, "key")
+		return "key:" + key
+	})
+	mux.Handle("/users/:slug([a-z0-9-]+)", func(req *http.Request) string {
+		slug, _ := PathParam(req, "slug")
+		return "slug:" + slug
+	})
+	mux.Handle("/orders/:id(int)", func() string { return "order" })
+
+	tests := []struct {
+		path, expected string
+		code           int
+	}{
+		{"/users/me", "me", http.StatusOK},
+		{"/users/42", "id:42", http.StatusOK},
+		{"/users/0b8e1c4e-6f43-4c1a-9a53-1f7c1a2b3c4d", "key:0b8e1c4e-6f43-4c1a-9a53-1f7c1a2b3c4d", http.StatusOK},
+		{"/users/sam-1", "slug:sam-1", http.StatusOK},
+		{"/users/Sam", "", http.StatusNotFound},
+		{"/orders/abc", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest("GET", test.path, nil))
+		if res.Code != test.code {
+			t.Fatalf(`%s: res.Code != %d, res.Code == %d`, test.path, test.code, res.Code)
+		}
+		var result string
+		json.NewDecoder(res.Body).Decode(&result)
+		if res.Code == http.StatusOK && result != test.expected {
+			t.Fatalf(`%s: result != %q, result == %q`, test.path, test.expected, result)
+		}
+	}
+}
+
+func TestInvalidPathConstraint(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected an invalid constraint to panic")
+		}
+	}()
+	NewServeMux().Handle("/users/:id([a-z)", func() {})
+}
+
+func TestPathConstraintDocumentation(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/users/:id(int)", func() {})
+	mux.Handle("/tags/:slug([a-z]+)", func() {})
+
+	for _, e := range mux.Documentation().Endpoints {
+		for _, param := range e.PathParams {
+			if param.Constraint != "int" && param.Constraint != "[a-z]+" {
+				t.Fatalf(`unexpected constraint for %s: %q`, e.Path, param.Constraint)
+			}
+		}
+	}
+
+	spec := mux.OpenAPI("test", "1")
+	if schema := spec.Paths["/users/{id}"].Get.Parameters[0].Schema; schema.Type != "integer" {
+		t.Fatalf(`schema.Type != "integer", schema.Type == %q`, schema.Type)
+	}
+	if schema := spec.Paths["/tags/{slug}"].Get.Parameters[0].Schema; schema.Pattern != "^(?:[a-z]+)$" {
+		t.Fatalf(`unexpected pattern %q`, schema.Pattern)
+	}
+}
+
+func TestOpenAPIPathCollision(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	mux := NewServeMux()
+	mux.Handle("/u/:id(int)", func() {})
+	mux.Handle("/u/:id([a-z]+)", &ByMethod{POST: func() {}})
+	mux.Handle("/v/:id(int)", func() {})
+	mux.Handle("/v/:id([a-z]+)", func() {})
+	mux.Handle("/user/:userId(int)", func() {})
+	mux.Handle("/user/:slug([a-z]+)", func() {})
+
+	spec := mux.OpenAPI("test", "1")
+
+	if item := spec.Paths["/u/{id}"]; item == nil || item.Get == nil || item.Post == nil {
+		t.Fatalf(`expected /u/{id} to have both operations, got %#v`, item)
+	}
+	if item := spec.Paths["/v/{id}"]; item == nil || item.Get == nil {
+		t.Fatalf(`expected /v/{id} to have a get operation, got %#v`, item)
+	}
+	if _, ok := spec.Paths["/user/{slug}"]; !ok {
+		t.Fatalf(`expected /user/{slug} to be documented`)
+	}
+	if _, ok := spec.Paths["/user/{userId}"]; ok {
+		t.Fatalf(`expected /user/{userId} to be left out`)
+	}
+
+	for _, warning := range []string{
+		"the GET operation of route /v/:id(int) is left out",
+		"route /user/:userId(int) is left out",
+	} {
+		if !strings.Contains(logged.String(), warning) {
+			t.Fatalf(`expected a warning containing %q, got %q`, warning, logged.String())
+		}
+	}
+
+	res := httptest.NewRecorder()
+	mux.OpenAPIHandler("test", "1").ServeHTTP(res, httptest.NewRequest("GET", "/openapi.json", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf(`res.Code != http.StatusOK, res.Code == %d`, res.Code)
+	}
+}
+
+type userIdPathParam int
+
+type orderParams struct {
+	OrderId int `plumbus:"path=orderId"`
+}
+
+func TestPathParamsMustBeInRoute(t *testing.T) {
+	tests := []struct {
+		route   string
+		handler interface{}
+		param   string
+	}{
+		{"/users", func(id userIdPathParam) {}, "userId"},
+		{"/users/:id", &ByMethod{GET: func(id userIdPathParam) {}}, "userId"},
+		{"/orders", func(params orderParams) {}, "orderId"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				expected := "Error while routing " + test.route + ": route " + test.route +
+					" has no variable for path parameter '" + test.param + "'"
+				if err == nil || err.Error() != expected {
+					t.Fatalf(`expected error %q, got %v`, expected, err)
+				}
+			}()
+			NewServeMux().Handle(test.route, test.handler)
+		}()
+	}
+
+	mux := NewServeMux()
+	mux.Handle("/users/:userId(int)", func(id userIdPathParam) {})
+	mux.Handle("/orders/:orderId", &ByMethod{GET: func(params orderParams) {}})
+}
+
+func TestRootRoute(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/", func() string { return "root" })
+	mux.Handle("/about", func() string { return "about" })
+
+	for path, expected := range map[string]string{"/": "root", "/about": "about"} {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		var result string
+		json.NewDecoder(res.Body).Decode(&result)
+		if result != expected {
+			t.Fatalf(`%s: result != %q, result == %q`, path, expected, result)
+		}
+	}
+
+	paths := map[string]bool{}
+	for _, e := range mux.Documentation().Endpoints {
+		paths[e.Path] = true
+	}
+	if !paths["/"] || !paths["/about"] {
+		t.Fatalf("expected endpoints for / and /about, got %v", paths)
+	}
+}
+
+func TestDeterministicBacktracking(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/a/:x/b", func(req *http.Request) string {
+		x, _ := PathParam(req, "x")
+		return "x:" + x
+	})
+	mux.Handle("/a/:y/c", func(req *http.Request) string {
+		y, _ := PathParam(req, "y")
+		return "y:" + y
+	})
+
+	for i := 0; i < 20; i++ {
+		for path, expected := range map[string]string{"/a/1/b": "x:1", "/a/2/c": "y:2"} {
+			res := httptest.NewRecorder()
+			mux.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+			var result string
+			json.NewDecoder(res.Body).Decode(&result)
+			if result != expected {
+				t.Fatalf(`%s: result != %q, result == %q`, path, expected, result)
+			}
+		}
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	tests := []struct {
+		first, second string
+	}{
+		{"/a/b", "/a/b/"},
+		{"/a/:x", "/a/:y"},
+		{"/a/:x/b", "/a/:y/b"},
+		{"/a/:x(int)", "/a/:y(int)"},
+		{"/static/*path", "/static/*rest"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				expected := "Error while routing " + test.second + ": route " + test.second + " conflicts with " + test.first
+				if err == nil || err.Error() != expected {
+					t.Fatalf(`expected error %q, got %v`, expected, err)
+				}
+			}()
+			mux := NewServeMux()
+			mux.Handle(test.first, func() {})
+			mux.Handle(test.second, func() {})
+		}()
+	}
+
+	//these overlap, but which one matches is well defined
+	mux := NewServeMux()
+	mux.Handle("/a/:x", func() {})
+	mux.Handle("/a/:x(int)", func() {})
+	mux.Handle("/a/b", func() {})
+	mux.Handle("/a/*rest", func() {})
+}
+
+func TestPathParamsLeaveQueryAlone(t *testing.T) {
+	var rawQuery string
+	var userId string
+	var queryUserId []string
+	mux := NewServeMux()
+	mux.Handle("/user/:userId", func(req *http.Request) {
+		rawQuery = req.URL.RawQuery
+		userId, _ = PathParam(req, "userId")
+		queryUserId = req.URL.Query()["userId"]
+	})
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", "/user/10?z=1&a=2&userId=evil&sig=x%2By", nil))
+
+	if rawQuery != "z=1&a=2&userId=evil&sig=x%2By" {
+		t.Fatalf(`rawQuery != "z=1&a=2&userId=evil&sig=x%%2By", rawQuery == %q`, rawQuery)
+	}
+	if userId != "10" {
+		t.Fatalf(`userId != "10", userId == %q`, userId)
+	}
+	if len(queryUserId) != 1 || queryUserId[0] != "evil" {
+		t.Fatalf(`expected only the client's userId in the query, got %v`, queryUserId)
+	}
+}
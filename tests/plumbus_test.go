@@ -3,9 +3,13 @@ package plumbus
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/jargv/plumbus"
 	. "github.com/jargv/plumbus/tests/handlers"
@@ -257,6 +261,385 @@ func TestOptionalRequestParam(t *testing.T) {
 	}
 }
 
+func TestContextArgument(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(ContextHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?food=nachos")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if !ContextHandlerHadContext {
+		t.Fatalf(`ContextHandlerHadContext != true`)
+	}
+}
+
+func TestRawRequestAndResponseWriter(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(RawRequestHandler))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"?food=nachos", nil)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	req.Header.Set("X-Food", "tacos")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if RawRequestHeader != "tacos" {
+		t.Fatalf(`RawRequestHeader != "tacos", RawRequestHeader == %q`, RawRequestHeader)
+	}
+
+	if header := resp.Header.Get("X-Food"); header != "nachos" {
+		t.Fatalf(`header != "nachos", header == %q`, header)
+	}
+
+	var result ReturnStructResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("couldn't decode: %v\n", err)
+	}
+
+	if result.Message != "Victory!" {
+		t.Fatalf(`result.Message != "Victory!", result.Message == %q`, result.Message)
+	}
+}
+
+func TestTypedPathParams(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/user/:userId/order/:orderId", TypedPathParamHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/user/10/order/ab?userId=query")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if TypedPathParamUserId != 10 {
+		t.Fatalf(`TypedPathParamUserId != 10, TypedPathParamUserId == %v`, TypedPathParamUserId)
+	}
+
+	if TypedPathParamOrderId != "ab" {
+		t.Fatalf(`TypedPathParamOrderId != "ab", TypedPathParamOrderId == %q`, TypedPathParamOrderId)
+	}
+
+	if TypedPathParamQuery != "query" {
+		t.Fatalf(`TypedPathParamQuery != "query", TypedPathParamQuery == %q`, TypedPathParamQuery)
+	}
+
+	for _, path := range []string{"/user/ten/order/ab", "/user/10/order/abc"} {
+		resp, err = http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf(`resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, resp.StatusCode)
+		}
+	}
+
+	docs := mux.Documentation()
+	if len(docs.Endpoints) != 1 {
+		t.Fatalf(`len(docs.Endpoints) != 1, len(docs.Endpoints) == %d`, len(docs.Endpoints))
+	}
+
+	pathParam, ok := docs.Endpoints[0].PathParams["userId"]
+	if !ok || pathParam.Type != "integer" || !pathParam.Required {
+		t.Fatalf("expected a required integer path param, got %#v", docs.Endpoints[0].PathParams)
+	}
+
+	if _, ok := docs.Endpoints[0].Params["userId"]; !ok {
+		t.Fatalf("expected a userId query param, got %#v", docs.Endpoints[0].Params)
+	}
+}
+
+func TestQueryParamKinds(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(QueryParamKindsHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?limit=-20&offset=5&ratio=0.5&active=true&since=2020-01-02T03:04:05Z&timeout=1m30s")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if QueryParamKindsLimit != -20 {
+		t.Fatalf(`QueryParamKindsLimit != -20, QueryParamKindsLimit == %v`, QueryParamKindsLimit)
+	}
+
+	if QueryParamKindsOffset != 5 {
+		t.Fatalf(`QueryParamKindsOffset != 5, QueryParamKindsOffset == %v`, QueryParamKindsOffset)
+	}
+
+	if QueryParamKindsRatio != 0.5 {
+		t.Fatalf(`QueryParamKindsRatio != 0.5, QueryParamKindsRatio == %v`, QueryParamKindsRatio)
+	}
+
+	if !QueryParamKindsActive {
+		t.Fatalf(`QueryParamKindsActive != true`)
+	}
+
+	if !QueryParamKindsSince.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf(`unexpected QueryParamKindsSince: %v`, QueryParamKindsSince)
+	}
+
+	if QueryParamKindsTimeout != 90*time.Second {
+		t.Fatalf(`QueryParamKindsTimeout != 90s, QueryParamKindsTimeout == %v`, QueryParamKindsTimeout)
+	}
+
+	for _, query := range []string{
+		"?limit=a&offset=5&ratio=0.5&since=2020-01-02T03:04:05Z",
+		"?limit=1&offset=-5&ratio=0.5&since=2020-01-02T03:04:05Z",
+		"?limit=1&offset=70000&ratio=0.5&since=2020-01-02T03:04:05Z",
+		"?limit=1&offset=5&ratio=half&since=2020-01-02T03:04:05Z",
+		"?limit=1&offset=5&ratio=0.5&active=maybe&since=2020-01-02T03:04:05Z",
+		"?limit=1&offset=5&ratio=0.5&since=yesterday",
+		"?limit=1&offset=5&ratio=0.5&since=2020-01-02T03:04:05Z&timeout=soon",
+	} {
+		resp, err = http.Get(server.URL + query)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf(`%s: resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, query, resp.StatusCode)
+		}
+	}
+}
+
+func TestSliceQueryParams(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(SliceQueryParamHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?tag=a&tag=b,c&id=1,2&id=3")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if !reflect.DeepEqual(SliceQueryParamTags, []string{"a", "b", "c"}) {
+		t.Fatalf(`SliceQueryParamTags != [a b c], SliceQueryParamTags == %v`, SliceQueryParamTags)
+	}
+
+	if !reflect.DeepEqual(SliceQueryParamIds, []int{1, 2, 3}) {
+		t.Fatalf(`SliceQueryParamIds != [1 2 3], SliceQueryParamIds == %v`, SliceQueryParamIds)
+	}
+
+	//ids are optional, tags are not
+	resp, err = http.Get(server.URL + "?tag=a")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || SliceQueryParamIds != nil {
+		t.Fatalf(`expected success without ids, got "%v" and %v`, resp.StatusCode, SliceQueryParamIds)
+	}
+
+	for _, query := range []string{"", "?id=1", "?tag=a&id=1,b"} {
+		resp, err = http.Get(server.URL + query)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf(`%s: resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, query, resp.StatusCode)
+		}
+	}
+
+	docs := NewServeMux()
+	docs.Handle("/tags", SliceQueryParamHandler)
+	tags := docs.Documentation().Endpoints[0].Params["tag"]
+	if tags.Type != "array" || tags.Items != "string" {
+		t.Fatalf(`expected an array of string, got %#v`, tags)
+	}
+}
+
+func TestParamsStruct(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/user/:userId/friends", ParamsStructHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/user/7/friends?tag=a,b", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	result := ListUsersResult
+	if result.UserId != 7 || result.Limit != 20 || result.Tenant != "acme" {
+		t.Fatalf(`unexpected params: %#v`, result)
+	}
+
+	if !reflect.DeepEqual(result.Tags, []string{"a", "b"}) {
+		t.Fatalf(`result.Tags != [a b], result.Tags == %v`, result.Tags)
+	}
+
+	if result.Session == nil || *result.Session != "abc" {
+		t.Fatalf(`expected session "abc", got %v`, result.Session)
+	}
+
+	if result.Timeout.Duration != 30*time.Second {
+		t.Fatalf(`result.Timeout != 30s, result.Timeout == %v`, result.Timeout)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/user/7/friends?tag=a&timeout=5s", nil)
+	req.Header.Set("X-Tenant", "acme")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if timeout := ListUsersResult.Timeout.Duration; timeout != 5*time.Second {
+		t.Fatalf(`timeout != 5s, timeout == %v`, timeout)
+	}
+
+	//every problem is reported at once
+	resp, err = http.Get(server.URL + "/user/7/friends?limit=lots")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf(`resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	for _, msg := range []string{"limit", "tag", "X-Tenant"} {
+		if !strings.Contains(string(body), msg) {
+			t.Fatalf("expected the error to mention %s, got %s", msg, body)
+		}
+	}
+
+	e := mux.Documentation().Endpoints[0]
+	if limit := e.Params["limit"]; limit.Required || limit.Default != "20" || limit.Type != "integer" {
+		t.Fatalf("unexpected limit param %#v", limit)
+	}
+	if _, ok := e.PathParams["userId"]; !ok {
+		t.Fatalf("expected a userId path param, got %#v", e.PathParams)
+	}
+	if tenant, ok := e.HeaderParams["X-Tenant"]; !ok || !tenant.Required {
+		t.Fatalf("expected a required X-Tenant header param, got %#v", e.HeaderParams)
+	}
+	if session, ok := e.CookieParams["session"]; !ok || session.Required {
+		t.Fatalf("expected an optional session cookie param, got %#v", e.CookieParams)
+	}
+}
+
+func TestHeaderAndCookieParams(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/tenant", HeaderCookieHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/tenant", nil)
+	req.Header.Set("X-Tenant-Id", "acme")
+	req.Header.Set("Api-Version", "2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if HeaderCookieTenant != "acme" {
+		t.Fatalf(`HeaderCookieTenant != "acme", HeaderCookieTenant == %q`, HeaderCookieTenant)
+	}
+
+	if HeaderCookieVersion != 2 {
+		t.Fatalf(`HeaderCookieVersion != 2, HeaderCookieVersion == %v`, HeaderCookieVersion)
+	}
+
+	if HeaderCookieSession != "abc" {
+		t.Fatalf(`HeaderCookieSession != "abc", HeaderCookieSession == %q`, HeaderCookieSession)
+	}
+
+	//the tenant and session are required
+	resp, err = http.Get(server.URL + "/tenant")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf(`resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	e := mux.Documentation().Endpoints[0]
+	if _, ok := e.HeaderParams["X-Tenant-Id"]; !ok {
+		t.Fatalf("expected an X-Tenant-Id header param, got %#v", e.HeaderParams)
+	}
+	if version, ok := e.HeaderParams["Api-Version"]; !ok || version.Required || version.Type != "integer" {
+		t.Fatalf("expected an optional integer Api-Version header param, got %#v", e.HeaderParams)
+	}
+	if _, ok := e.CookieParams["session"]; !ok {
+		t.Fatalf("expected a session cookie param, got %#v", e.CookieParams)
+	}
+
+	spec := mux.OpenAPI("test api", "1.0")
+	locations := map[string]string{}
+	for _, param := range spec.Paths["/tenant"].Get.Parameters {
+		locations[param.Name] = param.In
+	}
+	expected := map[string]string{"X-Tenant-Id": "header", "Api-Version": "header", "session": "cookie"}
+	if !reflect.DeepEqual(locations, expected) {
+		t.Fatalf("expected parameters %v, got %v", expected, locations)
+	}
+}
+
+func TestStructNamedLikeParam(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(PageHeaderHandler))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"title":"hello"}`))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	var title string
+	json.NewDecoder(resp.Body).Decode(&title)
+	if title != "hello" {
+		t.Fatalf(`title != "hello", title == %q`, title)
+	}
+}
+
 // // type UserId struct {
 // // }
 
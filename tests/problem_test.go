@@ -0,0 +1,137 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func problemServer() *httptest.Server {
+	mux := NewServeMux()
+	mux.ProblemDetails = true
+	mux.Handle("/credit", OutOfCreditHandler)
+	mux.Handle("/internal", InternalErrorHandler)
+	mux.Handle("/body", ByMethod{POST: RequestBodyHandler})
+	mux.Handle("/user/:userId/friends", ParamsStructHandler)
+	mux.Handle("/signup", SignupHandler)
+	return httptest.NewServer(mux)
+}
+
+func getProblem(t *testing.T, resp *http.Response, err error) map[string]interface{} {
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Fatalf(`contentType != "application/problem+json", contentType == %q`, contentType)
+	}
+	problem := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("decoding problem: %v", err)
+	}
+	if status := int(problem["status"].(float64)); status != resp.StatusCode {
+		t.Fatalf(`status != resp.StatusCode, status == %d, resp.StatusCode == %d`, status, resp.StatusCode)
+	}
+	return problem
+}
+
+func TestProblemDetails(t *testing.T) {
+	server := problemServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/credit")
+	problem := getProblem(t, resp, err)
+	expected := map[string]interface{}{
+		"type":     "https://example.com/problems/out-of-credit",
+		"title":    "You do not have enough credit.",
+		"status":   float64(http.StatusForbidden),
+		"detail":   "your balance is 30",
+		"instance": "/credit",
+		"balance":  float64(30),
+	}
+	if !reflect.DeepEqual(problem, expected) {
+		t.Fatalf("expected problem %v, got %v", expected, problem)
+	}
+
+	resp, err = http.Get(server.URL + "/internal")
+	problem = getProblem(t, resp, err)
+	expected = map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Internal Server Error",
+		"status":   float64(http.StatusInternalServerError),
+		"instance": "/internal",
+	}
+	if !reflect.DeepEqual(problem, expected) {
+		t.Fatalf("expected problem %v, got %v", expected, problem)
+	}
+}
+
+func TestProblemDetailsRequestErrors(t *testing.T) {
+	server := problemServer()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/body", "application/json", bytes.NewBufferString("{"))
+	problem := getProblem(t, resp, err)
+	if problem["title"] != "Bad Request" {
+		t.Fatalf(`problem["title"] != "Bad Request", problem["title"] == %v`, problem["title"])
+	}
+
+	resp, err = http.Get(server.URL + "/user/7/friends?limit=lots")
+	problem = getProblem(t, resp, err)
+	errs, _ := problem["errors"].([]interface{})
+	if resp.StatusCode != http.StatusBadRequest || len(errs) != 3 {
+		t.Fatalf("expected a 400 listing 3 errors, got %d: %v", resp.StatusCode, problem)
+	}
+
+	resp, err = http.Post(server.URL+"/signup", "application/json", bytes.NewBufferString(`{"age":20}`))
+	problem = getProblem(t, resp, err)
+	fields, _ := problem["fields"].([]interface{})
+	if resp.StatusCode != http.StatusUnprocessableEntity || len(fields) != 2 {
+		t.Fatalf("expected a 422 listing 2 fields, got %d: %v", resp.StatusCode, problem)
+	}
+}
+
+func TestProblemDetailsRouting(t *testing.T) {
+	server := problemServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/nowhere")
+	problem := getProblem(t, resp, err)
+	if resp.StatusCode != http.StatusNotFound || problem["instance"] != "/nowhere" {
+		t.Fatalf("expected a 404 for /nowhere, got %d: %v", resp.StatusCode, problem)
+	}
+
+	resp, err = http.Get(server.URL + "/body")
+	problem = getProblem(t, resp, err)
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf(`resp.StatusCode != http.StatusMethodNotAllowed, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+	if problem["detail"] != "method GET not allowed, expected {POST}" {
+		t.Fatalf(`unexpected detail %q`, problem["detail"])
+	}
+	if allow := resp.Header.Get("Allow"); allow != "POST" {
+		t.Fatalf(`allow != "POST", allow == %q`, allow)
+	}
+}
+
+func TestDefaultErrorFormat(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/credit", OutOfCreditHandler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/credit")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	body := map[string]interface{}{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if !reflect.DeepEqual(body, map[string]interface{}{"error": "your balance is 30"}) {
+		t.Fatalf(`unexpected error body %v`, body)
+	}
+}
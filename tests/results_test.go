@@ -0,0 +1,92 @@
+package plumbus
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestResponseCodes(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/echo", &ByMethod{
+		POST:   CreatedHandler,
+		DELETE: NoContentHandler,
+	})
+	mux.Handle("/status", StatusHandler)
+	mux.Handle("/queued", BodyResponseCodeHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/echo", "application/json", strings.NewReader(`{"message":"hi"}`))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf(`resp.StatusCode != http.StatusCreated, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	if location := resp.Header.Get("Location"); location != "/echo/hi" {
+		t.Fatalf(`location != "/echo/hi", location == %q`, location)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf(`contentType != "application/json", contentType == %q`, contentType)
+	}
+
+	req, _ := http.NewRequest("DELETE", server.URL+"/echo", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if resp.StatusCode != http.StatusNoContent || !NoContentCalled {
+		t.Fatalf(`expected a 204 response, got "%v"`, resp.StatusCode)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if len(body) != 0 {
+		t.Fatalf(`expected no body, got %q`, body)
+	}
+
+	for _, path := range []string{"/status", "/queued"} {
+		resp, err = http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("making request: %v\n", err)
+		}
+
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf(`%s: resp.StatusCode != http.StatusAccepted, resp.StatusCode == "%v"`, path, resp.StatusCode)
+		}
+	}
+
+	statuses := map[string]int{}
+	for _, e := range mux.Documentation().Endpoints {
+		statuses[e.Method+" "+e.Path] = e.Status
+	}
+	expected := map[string]int{
+		"POST /echo":   http.StatusCreated,
+		"DELETE /echo": http.StatusNoContent,
+		" /status":     http.StatusOK,
+		" /queued":     http.StatusAccepted,
+	}
+	for endpoint, status := range expected {
+		if statuses[endpoint] != status {
+			t.Fatalf("%s: expected status %d, got %d", endpoint, status, statuses[endpoint])
+		}
+	}
+
+	spec := mux.OpenAPI("test api", "1.0")
+	if _, ok := spec.Paths["/echo"].Post.Responses["201"]; !ok {
+		t.Fatalf("expected a 201 response, got %#v", spec.Paths["/echo"].Post.Responses)
+	}
+	if noContent, ok := spec.Paths["/echo"].Delete.Responses["204"]; !ok || noContent.Content != nil {
+		t.Fatalf("expected a 204 response without content, got %#v", spec.Paths["/echo"].Delete.Responses)
+	}
+}
@@ -0,0 +1,89 @@
+package plumbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func TestStreamReader(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(ReaderHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "line one\nline two\n" {
+		t.Fatalf(`unexpected body %q`, body)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/octet-stream" {
+		t.Fatalf(`contentType != "application/octet-stream", contentType == %q`, contentType)
+	}
+
+	if !ReaderClosed {
+		t.Fatalf("expected the reader to be closed")
+	}
+}
+
+func TestStreamChannel(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(ChannelHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Fatalf(`contentType != "application/x-ndjson", contentType == %q`, contentType)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for i := 1; i <= 3; i++ {
+		var echo EchoBody
+		if err := dec.Decode(&echo); err != nil {
+			t.Fatalf("decoding line %d: %v", i, err)
+		}
+		if echo.Count != i {
+			t.Fatalf(`echo.Count != %d, echo.Count == %d`, i, echo.Count)
+		}
+	}
+
+	if dec.More() {
+		t.Fatalf("expected the stream to end after the channel closed")
+	}
+}
+
+func TestStreamChannelDisconnect(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(EndlessChannelHandler))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+
+	//values arrive as they're sent, without waiting for the end
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "0\n" {
+		t.Fatalf(`expected the first line "0\n", got %q (%v)`, line, err)
+	}
+
+	cancel()
+	resp.Body.Close()
+
+	//blocks until the handler stops streaming
+	server.Close()
+}
@@ -0,0 +1,167 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"reflect"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+type uploadPart struct {
+	field, filename, contentType, content string
+}
+
+func postMultipart(t *testing.T, url string, parts ...uploadPart) *http.Response {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		if part.filename == "" {
+			writer.WriteField(part.field, part.content)
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+part.field+`"; filename="`+part.filename+`"`)
+		header.Set("Content-Type", part.contentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatalf("creating part: %v", err)
+		}
+		w.Write([]byte(part.content))
+	}
+	writer.Close()
+
+	resp, err := http.Post(url, writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	return resp
+}
+
+func TestFileUpload(t *testing.T) {
+	defer func(memory int64) {
+		MaxMultipartMemory = memory
+	}(MaxMultipartMemory)
+	MaxMultipartMemory = 1
+
+	server := httptest.NewServer(HandlerFunc(UploadHandler))
+	defer server.Close()
+
+	resp := postMultipart(t, server.URL, uploadPart{"doc", "a.txt", "text/plain", "hello"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	var result string
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result != "a.txt: hello" {
+		t.Fatalf(`result != "a.txt: hello", result == %q`, result)
+	}
+
+	//the upload was too big to keep in memory
+	if UploadTempFile == "" {
+		t.Fatalf("expected the upload to be stored in a temporary file")
+	}
+	if _, err := os.Stat(UploadTempFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file to be removed, got %v", err)
+	}
+
+	resp = postMultipart(t, server.URL, uploadPart{field: "name", content: "no files"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf(`resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	resp, err := http.Post(server.URL, "application/json", bytes.NewBufferString("{}"))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf(`resp.StatusCode != http.StatusUnsupportedMediaType, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+}
+
+func TestFileUploads(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(UploadsHandler))
+	defer server.Close()
+
+	resp := postMultipart(t, server.URL,
+		uploadPart{"b", "b.txt", "text/plain", "b"},
+		uploadPart{"a", "a1.txt", "text/plain", "a"},
+		uploadPart{"a", "a2.txt", "text/plain", "a"},
+	)
+
+	var names []string
+	json.NewDecoder(resp.Body).Decode(&names)
+	if !reflect.DeepEqual(names, []string{"a1.txt", "a2.txt", "b.txt"}) {
+		t.Fatalf(`names != [a1.txt a2.txt b.txt], names == %v`, names)
+	}
+}
+
+func TestMultipartForm(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/avatar", AvatarHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp := postMultipart(t, server.URL+"/avatar",
+		uploadPart{field: "name", content: "sam"},
+		uploadPart{field: "age", content: "30"},
+		uploadPart{"avatar", "me.png", "image/png", "png!"},
+		uploadPart{"extras", "1.txt", "text/plain", "1"},
+		uploadPart{"extras", "2.txt", "text/plain", "2"},
+	)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v": %s`, resp.StatusCode, body)
+	}
+
+	result := AvatarFormResult
+	if result.Name != "sam" || result.Age != 30 {
+		t.Fatalf(`unexpected form fields %q and %d`, result.Name, result.Age)
+	}
+	if result.Avatar.Filename != "me.png" || result.Avatar.ContentType != "image/png" || result.Avatar.Size != 4 {
+		t.Fatalf(`unexpected avatar %#v`, result.Avatar)
+	}
+	if len(result.Extras) != 2 || result.Note != nil {
+		t.Fatalf(`expected 2 extras and no note, got %d and %v`, len(result.Extras), result.Note)
+	}
+
+	resp = postMultipart(t, server.URL+"/avatar", uploadPart{"avatar", "me.jpg", "image/jpeg", "jpg!"})
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf(`resp.StatusCode != http.StatusUnsupportedMediaType, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	resp = postMultipart(t, server.URL+"/avatar", uploadPart{field: "name", content: "sam"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf(`resp.StatusCode != http.StatusBadRequest, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	defer func(size int64) {
+		MaxUploadSize = size
+	}(MaxUploadSize)
+	MaxUploadSize = 100
+
+	resp = postMultipart(t, server.URL+"/avatar", uploadPart{"avatar", "me.png", "image/png", string(make([]byte, 200))})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf(`resp.StatusCode != http.StatusRequestEntityTooLarge, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	uploads := mux.Documentation().Endpoints[0].Uploads
+	expected := map[string]UploadInfo{
+		"avatar": {Required: true, Accept: []string{"image/png", "image/gif"}, MaxSize: 100},
+		"extras": {Multiple: true, MaxSize: 100},
+		"note":   {MaxSize: 100},
+	}
+	if !reflect.DeepEqual(uploads, expected) {
+		t.Fatalf("expected uploads %#v, got %#v", expected, uploads)
+	}
+}
@@ -0,0 +1,103 @@
+package plumbus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+func urlMux() *ServeMux {
+	mux := NewServeMux()
+	mux.Handle("/", Route{Handler: func() {}, Name: "home"})
+	mux.Handle("/user/:userId(int)/order/:orderId", Route{Handler: TypedPathParamHandler, Name: "userOrder"})
+	mux.Handle("/static/*filepath", Route{Handler: StaticFileHandler, Name: "static"})
+	mux.Handle("/unnamed", func() {})
+
+	api := mux.Group("/api")
+	api.Handle("/tags/:tag", Route{Handler: func() {}, Name: "tag"})
+	return mux
+}
+
+func TestURL(t *testing.T) {
+	mux := urlMux()
+
+	tests := []struct {
+		name     string
+		params   []string
+		expected string
+	}{
+		{"home", nil, "/"},
+		{"userOrder", []string{"userId", "10", "orderId", "ab"}, "/user/10/order/ab"},
+		{"static", []string{"filepath", "css/my site.css"}, "/static/css/my%20site.css"},
+		{"tag", []string{"tag", "a/b?c"}, "/api/tags/a%2Fb%3Fc"},
+	}
+
+	for _, test := range tests {
+		url, err := mux.URL(test.name, test.params...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if url != test.expected {
+			t.Fatalf(`%s: url != %q, url == %q`, test.name, test.expected, url)
+		}
+	}
+
+	//the URL built is routed back to the same handler
+	url, _ := mux.URL("userOrder", "userId", "10", "orderId", "ab")
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", url, nil))
+	if res.Code != http.StatusOK || TypedPathParamUserId != 10 || TypedPathParamOrderId != "ab" {
+		t.Fatalf("expected %s to reach the handler, got %d", url, res.Code)
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	mux := urlMux()
+
+	tests := []struct {
+		name     string
+		params   []string
+		expected string
+	}{
+		{"nope", nil, `no route named "nope"`},
+		{"userOrder", []string{"userId", "10"}, `route "userOrder": missing param "orderId"`},
+		{"userOrder", []string{"userId", "ten", "orderId", "ab"}, `route "userOrder": param "userId" doesn't match (int): "ten"`},
+		{"userOrder", []string{"userId", "10", "orderId", "ab", "extra", "1"}, `route "userOrder": unknown param "extra"`},
+		{"userOrder", []string{"userId"}, `route "userOrder": params must be pairs of names and values`},
+		{"tag", []string{"tag", ""}, `route "tag": param "tag" is empty`},
+	}
+
+	for _, test := range tests {
+		_, err := mux.URL(test.name, test.params...)
+		if err == nil || err.Error() != test.expected {
+			t.Fatalf(`expected error %q, got %v`, test.expected, err)
+		}
+	}
+}
+
+func TestDuplicateRouteName(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		expected := `Error while routing /b: route name "a" is already used by /a`
+		if err == nil || err.Error() != expected {
+			t.Fatalf(`expected error %q, got %v`, expected, err)
+		}
+	}()
+	mux := NewServeMux()
+	mux.Handle("/a", Route{Handler: func() {}, Name: "a"})
+	mux.Handle("/b", Route{Handler: func() {}, Name: "a"})
+}
+
+func TestRouteNameDocumentation(t *testing.T) {
+	for _, e := range urlMux().Documentation().Endpoints {
+		if e.Path == "/api/tags/:tag" && e.Name != "tag" {
+			t.Fatalf(`e.Name != "tag", e.Name == %q`, e.Name)
+		}
+		if e.Path == "/unnamed" && e.Name != "" {
+			t.Fatalf(`e.Name != "", e.Name == %q`, e.Name)
+		}
+	}
+}
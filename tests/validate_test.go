@@ -0,0 +1,197 @@
+package plumbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/jargv/plumbus"
+	. "github.com/jargv/plumbus/tests/handlers"
+)
+
+type validationResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+func postSignup(t *testing.T, url string, body string) (*http.Response, validationResponse) {
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	var result validationResponse
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		json.NewDecoder(resp.Body).Decode(&result)
+	}
+	return resp, result
+}
+
+func TestValidationTags(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(SignupHandler))
+	defer server.Close()
+
+	resp, _ := postSignup(t, server.URL, `{"email":"a@b.c","age":20,"password":"hunter22","confirm":"hunter22"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf(`resp.StatusCode != http.StatusOK, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	resp, result := postSignup(t, server.URL, `{
+		"email": "nope",
+		"age": 5,
+		"plan": "gold",
+		"password": "short",
+		"confirm": "short",
+		"tags": ["a", "b", "c", "d"],
+		"address": {"zip": "123"}
+	}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf(`resp.StatusCode != http.StatusUnprocessableEntity, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+
+	expected := []FieldError{
+		{Field: "email", Message: "must match ^[^@]+@[^@]+$"},
+		{Field: "age", Message: "must be at least 13"},
+		{Field: "plan", Message: "must be one of free, pro"},
+		{Field: "password", Message: "must be at least 8 characters long"},
+		{Field: "tags", Message: "must have at most 3 elements"},
+		{Field: "address.zip", Message: "must be exactly 5 characters long"},
+	}
+	if !reflect.DeepEqual(result.Fields, expected) {
+		t.Fatalf("expected field errors %v, got %v", expected, result.Fields)
+	}
+
+	resp, result = postSignup(t, server.URL, `{"age":20,"password":"hunter22","confirm":"hunter22"}`)
+	expected = []FieldError{{Field: "email", Message: "is required"}}
+	if !reflect.DeepEqual(result.Fields, expected) {
+		t.Fatalf("expected field errors %v, got %v", expected, result.Fields)
+	}
+}
+
+func TestValidateMethod(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(SignupHandler))
+	defer server.Close()
+
+	resp, result := postSignup(t, server.URL, `{"email":"a@b.c","age":20,"password":"hunter22","confirm":"hunter23"}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf(`resp.StatusCode != http.StatusUnprocessableEntity, resp.StatusCode == "%v"`, resp.StatusCode)
+	}
+	expected := []FieldError{{Message: "passwords don't match"}}
+	if !reflect.DeepEqual(result.Fields, expected) {
+		t.Fatalf("expected field errors %v, got %v", expected, result.Fields)
+	}
+}
+
+func TestValidateZeroNumbers(t *testing.T) {
+	type order struct {
+		Quantity int      `json:"quantity" validate:"min=1"`
+		Note     string   `json:"note" validate:"min=3"`
+		Discount *int     `json:"discount" validate:"max=50"`
+		Tags     []string `json:"tags" validate:"min=1"`
+	}
+
+	err := Validate(&order{})
+	expected := ValidationErrors{{Field: "quantity", Message: "must be at least 1"}}
+	if !reflect.DeepEqual(err, expected) {
+		t.Fatalf(`err != %v, err == %v`, expected, err)
+	}
+
+	if err := Validate(&order{Quantity: 1}); err != nil {
+		t.Fatalf(`err != nil, err == %v`, err)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(SearchHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?q=plumbus")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	var limit int
+	json.NewDecoder(resp.Body).Decode(&limit)
+	if limit != 10 {
+		t.Fatalf(`limit != 10, limit == %d`, limit)
+	}
+
+	resp, err = http.Get(server.URL + "?q=&limit=1000")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	var result validationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	expected := []FieldError{
+		{Field: "q", Message: "is required"},
+		{Field: "limit", Message: "must be at most 100"},
+	}
+	if !reflect.DeepEqual(result.Fields, expected) {
+		t.Fatalf("expected field errors %v, got %v", expected, result.Fields)
+	}
+}
+
+type pageParams struct {
+	Limit int `plumbus:"default=20,query=limit" validate:"max=50"`
+	Page  int `plumbus:"query" validate:"max=5"`
+}
+
+func TestValidationParamNames(t *testing.T) {
+	server := httptest.NewServer(HandlerFunc(func(params pageParams) {}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?limit=100&page=6")
+	if err != nil {
+		t.Fatalf("making request: %v\n", err)
+	}
+	var result validationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	expected := []FieldError{
+		{Field: "limit", Message: "must be at most 50"},
+		{Field: "page", Message: "must be at most 5"},
+	}
+	if !reflect.DeepEqual(result.Fields, expected) {
+		t.Fatalf("expected field errors %v, got %v", expected, result.Fields)
+	}
+}
+
+func TestBadValidateTagPanicsOnHandle(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if err == nil || !strings.Contains(err.Error(), "requird") {
+			t.Fatalf(`expected a panic about the "requird" rule, got %v`, err)
+		}
+	}()
+	NewServeMux().Handle("/bad", BadValidateTagHandler)
+}
+
+func TestValidationSchema(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/signup", SignupHandler)
+
+	schema := mux.Documentation().Types["Signup"].Schema
+	props := schema.Properties
+
+	if props["email"].Pattern != "^[^@]+@[^@]+$" {
+		t.Fatalf(`unexpected pattern for email: %q`, props["email"].Pattern)
+	}
+	if *props["age"].Minimum != 13 || *props["age"].Maximum != 130 {
+		t.Fatalf(`unexpected bounds for age: %v, %v`, *props["age"].Minimum, *props["age"].Maximum)
+	}
+	if *props["password"].MinLength != 8 {
+		t.Fatalf(`*props["password"].MinLength != 8, *props["password"].MinLength == %d`, *props["password"].MinLength)
+	}
+	if *props["tags"].MaxItems != 3 {
+		t.Fatalf(`*props["tags"].MaxItems != 3, *props["tags"].MaxItems == %d`, *props["tags"].MaxItems)
+	}
+	if !reflect.DeepEqual(props["plan"].Enum, []interface{}{"free", "pro"}) {
+		t.Fatalf(`unexpected enum for plan: %v`, props["plan"].Enum)
+	}
+
+	zip := mux.Documentation().Types["Address"].Schema.Properties["zip"]
+	if *zip.MinLength != 5 || *zip.MaxLength != 5 {
+		t.Fatalf(`unexpected length bounds for zip: %v, %v`, *zip.MinLength, *zip.MaxLength)
+	}
+}
@@ -0,0 +1,257 @@
+package plumbus
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// File is an uploaded file from a multipart/form-data request. It can be
+// taken as an argument, with *File for an optional file and []File for all of
+// them, or as a field of a request body struct named by its `form` tag. Files
+// are closed and removed once the response has been sent.
+type File struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	multipart.File
+}
+
+var (
+	// MaxMultipartMemory is how much of a multipart request is kept in
+	// memory. The rest of the uploaded files are stored in temporary files.
+	MaxMultipartMemory int64 = 32 << 20
+
+	// MaxUploadSize limits the size of a multipart request, larger requests
+	// get a 413 response. Zero means there's no limit.
+	MaxUploadSize int64 = 0
+)
+
+var fileType = reflect.TypeOf(File{})
+
+// openFiles holds the files opened for each multipart form, so they can be
+// closed once the response has been sent
+var openFiles = struct {
+	sync.Mutex
+	forms map[*multipart.Form][]multipart.File
+}{
+	forms: map[*multipart.Form][]multipart.File{},
+}
+
+// BindFiles fills target, which points to a File, *File, or []File, with the
+// files uploaded in the request. A File is required, so a request without
+// any gets a 400 response.
+func BindFiles(req *http.Request, target interface{}) error {
+	if err := parseMultipart(req); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(req.MultipartForm.File))
+	for name := range req.MultipartForm.File {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []*multipart.FileHeader{}
+	for _, name := range names {
+		headers = append(headers, req.MultipartForm.File[name]...)
+	}
+
+	return bindFileHeaders(req, reflect.ValueOf(target).Elem(), headers, "", nil)
+}
+
+// CleanupMultipart closes the files uploaded in the request and removes any
+// temporary files they were stored in
+func CleanupMultipart(req *http.Request) {
+	form := req.MultipartForm
+	if form == nil {
+		return
+	}
+
+	openFiles.Lock()
+	files := openFiles.forms[form]
+	delete(openFiles.forms, form)
+	openFiles.Unlock()
+
+	for _, file := range files {
+		file.Close()
+	}
+	form.RemoveAll()
+}
+
+func parseMultipart(req *http.Request) error {
+	if req.MultipartForm != nil {
+		return nil
+	}
+
+	if MaxUploadSize > 0 {
+		req.Body = http.MaxBytesReader(nil, req.Body, MaxUploadSize)
+	}
+
+	err := req.ParseMultipartForm(MaxMultipartMemory)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, http.ErrNotMultipart):
+		return Errorf(http.StatusUnsupportedMediaType, "expected a multipart/form-data request")
+	case errors.As(err, &tooLarge):
+		return Errorf(http.StatusRequestEntityTooLarge, "uploads are limited to %d bytes", MaxUploadSize)
+	}
+	return Errorf(http.StatusBadRequest, "reading multipart form: %s", err.Error())
+}
+
+// decodeMultipart fills the struct v points to from a multipart form. Fields
+// are named like they are for url encoded forms, and File fields can only
+// hold files with the content types in their `accept` tag, if they have one.
+func decodeMultipart(req *http.Request, v interface{}) error {
+	if err := parseMultipart(req); err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return Errorf(http.StatusUnsupportedMediaType, "can't decode a multipart form into %s", val.Type())
+	}
+
+	form := req.MultipartForm
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		name := formFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		if isFileField(field.Type) {
+			err := bindFileHeaders(req, val.Field(i), form.File[name], name, acceptedTypes(field))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(form.Value[name]) == 0 {
+			continue
+		}
+		if err := setFormField(val.Field(i), form.Value[name]); err != nil {
+			return Errorf(http.StatusBadRequest, "form field '%s': %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func bindFileHeaders(req *http.Request, target reflect.Value, headers []*multipart.FileHeader, name string, accept []string) error {
+	describe := "file upload"
+	if name != "" {
+		describe = "file '" + name + "'"
+	}
+
+	files := []File{}
+	for _, header := range headers {
+		contentType := header.Header.Get("Content-Type")
+		if !contentTypeAccepted(contentType, accept) {
+			return Errorf(
+				http.StatusUnsupportedMediaType,
+				"%s must be one of %s, got '%s'",
+				describe,
+				strings.Join(accept, ", "),
+				contentType,
+			)
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return err
+		}
+		openFiles.Lock()
+		openFiles.forms[req.MultipartForm] = append(openFiles.forms[req.MultipartForm], file)
+		openFiles.Unlock()
+
+		files = append(files, File{
+			Filename:    header.Filename,
+			ContentType: contentType,
+			Size:        header.Size,
+			File:        file,
+		})
+	}
+
+	switch target.Kind() {
+	case reflect.Slice:
+		target.Set(reflect.ValueOf(files))
+	case reflect.Ptr:
+		if len(files) > 0 {
+			target.Set(reflect.ValueOf(&files[0]))
+		}
+	default:
+		if len(files) == 0 {
+			return Errorf(http.StatusBadRequest, "missing required %s", describe)
+		}
+		target.Set(reflect.ValueOf(files[0]))
+	}
+
+	return nil
+}
+
+func isFileField(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ == fileType
+}
+
+func acceptedTypes(field reflect.StructField) []string {
+	var accept []string
+	for _, mediaType := range strings.Split(field.Tag.Get("accept"), ",") {
+		if mediaType = strings.TrimSpace(mediaType); mediaType != "" {
+			accept = append(accept, mediaType)
+		}
+	}
+	return accept
+}
+
+func contentTypeAccepted(contentType string, accept []string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, accepted := range accept {
+		if mediaTypeMatches(accepted, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFileFields checks for a struct with File fields, which is read from a
+// multipart form
+func hasFileFields(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if formFieldName(field) != "" && isFileField(field.Type) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package plumbus
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL builds the path of the route registered with the name given, filling
+// in its variables from params, which alternate between the name of a
+// variable and its value:
+//
+//	mux.URL("userOrder", "userId", "10", "orderId", "ab")
+//
+// Values are escaped, except for the slashes in a wildcard's value. Every
+// variable must be given, and must match its constraint if it has one.
+func (sm *ServeMux) URL(name string, params ...string) (string, error) {
+	route, exists := sm.names[name]
+	if !exists {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %q: params must be pairs of names and values", name)
+	}
+	values := map[string]string{}
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	segments := getSegments(route)
+	for i, segment := range segments {
+		variable, isWildcard, ok := pathVariable(segment)
+		if !ok {
+			continue
+		}
+
+		value, given := values[variable]
+		if !given {
+			return "", fmt.Errorf("route %q: missing param %q", name, variable)
+		}
+		delete(values, variable)
+
+		if isWildcard {
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+
+		if value == "" {
+			return "", fmt.Errorf("route %q: param %q is empty", name, variable)
+		}
+		_, constraint, _ := parsePathVariable(segment[1:])
+		if !constraint.matches(value) {
+			return "", fmt.Errorf("route %q: param %q doesn't match (%s): %q", name, variable, constraint.source, value)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+
+	for variable := range values {
+		return "", fmt.Errorf("route %q: unknown param %q", name, variable)
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,402 @@
+package plumbus
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jargv/plumbus/generate"
+)
+
+// Validator can be implemented by a request body or parameter struct to check
+// it before the handler is called. It's called after any `validate` tags on
+// the struct's fields have passed.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is a problem with a single field of a request
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the response to a request that fails validation. Its
+// field errors are listed in the response body.
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Message
+		if fe.Field != "" {
+			msgs[i] = fe.Field + " " + fe.Message
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (ve ValidationErrors) ResponseCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+func (ve ValidationErrors) FieldErrors() []FieldError {
+	return ve
+}
+
+// fieldErrorer is implemented by errors with field errors to add to the
+// response body
+type fieldErrorer interface {
+	FieldErrors() []FieldError
+}
+
+// Validate checks v against the `validate` tags of its fields, then calls its
+// Validate method if it has one. Tags are a comma separated list of rules:
+//
+//	required      the value can't be the zero value
+//	min=N, max=N  bounds for numbers, or for the length of everything else
+//	len=N         the exact length
+//	oneof=a b c   the value must be one of those listed
+//	regex=EXPR    strings must match; this must be the last rule in the tag
+//
+// Rules other than required aren't checked on nil pointers or on strings,
+// slices and maps that are empty, so those fields are optional unless
+// they're required. Numbers are always checked, so min=1 rejects 0. Nested
+// structs are validated too.
+func Validate(v interface{}) error {
+	val := reflect.ValueOf(v)
+	var errs ValidationErrors
+	if err := validateValue(val, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	validator, ok := v.(Validator)
+	if !ok {
+		if val.Kind() == reflect.Ptr || !val.IsValid() {
+			return nil
+		}
+		//pointer receivers work even for arguments that aren't pointers
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		validator, ok = ptr.Interface().(Validator)
+		if !ok {
+			return nil
+		}
+	}
+
+	if val.Kind() == reflect.Ptr && val.IsNil() {
+		return nil
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+	if _, ok := AsHTTPError(err); ok {
+		return err
+	}
+	return ValidationErrors{{Message: err.Error()}}
+}
+
+// CheckValidateTags parses the `validate` tags of typ and of the structs it
+// holds, so a mistake in one is found when the handler taking it is
+// registered instead of on its first request
+func CheckValidateTags(typ reflect.Type) error {
+	return checkValidateTags(typ, map[reflect.Type]bool{})
+}
+
+func checkValidateTags(typ reflect.Type, seen map[reflect.Type]bool) error {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || seen[typ] {
+		return nil
+	}
+	seen[typ] = true
+
+	rules, err := structRules(typ)
+	if err != nil {
+		return err
+	}
+	for _, field := range rules {
+		if err := checkValidateTags(typ.Field(field.index).Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(val reflect.Value, path string, errs *ValidationErrors) error {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		rules, err := structRules(val.Type())
+		if err != nil {
+			return err
+		}
+		for _, field := range rules {
+			fieldVal := val.Field(field.index)
+			fieldPath := joinFieldPath(path, field.name)
+			for _, rule := range field.rules {
+				if rule.name != "required" && isEmptyValue(fieldVal) {
+					break
+				}
+				if msg := rule.check(fieldVal); msg != "" {
+					*errs = append(*errs, FieldError{Field: fieldPath, Message: msg})
+					break
+				}
+			}
+			if err := validateValue(fieldVal, fieldPath, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := validateValue(val.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+type validationRule struct {
+	name  string
+	arg   string
+	check func(val reflect.Value) string
+}
+
+type fieldRules struct {
+	index int
+	name  string
+	rules []validationRule
+}
+
+var rulesCache sync.Map
+
+// structRules gets the rules for each field of the struct type, which are
+// parsed once and cached
+func structRules(typ reflect.Type) ([]fieldRules, error) {
+	if cached, ok := rulesCache.Load(typ); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	fields := []fieldRules{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		rules, err := parseValidateTag(field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", typ, field.Name, err)
+		}
+		fields = append(fields, fieldRules{
+			index: i,
+			name:  validationFieldName(field),
+			rules: rules,
+		})
+	}
+
+	rulesCache.Store(typ, fields)
+	return fields, nil
+}
+
+// validationFieldName is how a field is referred to in errors, which is the
+// name it has in a request
+func validationFieldName(field reflect.StructField) string {
+	if param := generate.ParamFieldName(field); param != "" {
+		return param
+	}
+	for _, tagName := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func parseValidateTag(tag string) ([]validationRule, error) {
+	rules := []validationRule{}
+	for tag != "" {
+		option := tag
+		if strings.HasPrefix(tag, "regex=") {
+			//the expression may have commas of its own
+			tag = ""
+		} else if comma := strings.Index(tag, ","); comma != -1 {
+			option, tag = tag[:comma], tag[comma+1:]
+		} else {
+			tag = ""
+		}
+
+		name, arg := option, ""
+		if eq := strings.Index(option, "="); eq != -1 {
+			name, arg = option[:eq], option[eq+1:]
+		}
+
+		rule, err := makeRule(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func makeRule(name, arg string) (validationRule, error) {
+	rule := validationRule{name: name, arg: arg}
+
+	switch name {
+	case "required":
+		rule.check = func(val reflect.Value) string {
+			if val.IsZero() || (isLengthKind(val.Kind()) && val.Len() == 0) {
+				return "is required"
+			}
+			return ""
+		}
+
+	case "min", "max", "len":
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return rule, fmt.Errorf("%s needs a number, got '%s'", name, arg)
+		}
+		rule.check = func(val reflect.Value) string {
+			val, ok := derefValue(val)
+			if !ok {
+				return ""
+			}
+			n, isLength, ok := validationMeasure(val)
+			if !ok {
+				return ""
+			}
+			switch {
+			case name == "min" && n < bound:
+				return boundMessage("at least", arg, isLength, val.Kind())
+			case name == "max" && n > bound:
+				return boundMessage("at most", arg, isLength, val.Kind())
+			case name == "len" && n != bound:
+				return boundMessage("exactly", arg, true, val.Kind())
+			}
+			return ""
+		}
+
+	case "oneof":
+		allowed := strings.Fields(arg)
+		rule.check = func(val reflect.Value) string {
+			val, ok := derefValue(val)
+			if !ok {
+				return ""
+			}
+			value := fmt.Sprint(val.Interface())
+			for _, a := range allowed {
+				if value == a {
+					return ""
+				}
+			}
+			return "must be one of " + strings.Join(allowed, ", ")
+		}
+
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return rule, fmt.Errorf("invalid regex: %v", err)
+		}
+		rule.check = func(val reflect.Value) string {
+			val, ok := derefValue(val)
+			if !ok || val.Kind() != reflect.String {
+				return ""
+			}
+			if !re.MatchString(val.String()) {
+				return "must match " + arg
+			}
+			return ""
+		}
+
+	default:
+		return rule, fmt.Errorf("unknown validation rule '%s'", name)
+	}
+
+	return rule, nil
+}
+
+func derefValue(val reflect.Value) (reflect.Value, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return val, false
+		}
+		val = val.Elem()
+	}
+	return val, true
+}
+
+// isEmptyValue is whether val was left out, which is a nil pointer or an
+// empty value of a kind that has a length
+func isEmptyValue(val reflect.Value) bool {
+	val, ok := derefValue(val)
+	if !ok {
+		return true
+	}
+	return isLengthKind(val.Kind()) && val.Len() == 0
+}
+
+func isLengthKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+// validationMeasure is the number min and max compare against: the value of a
+// number, or the length of anything else that has one
+func validationMeasure(val reflect.Value) (n float64, isLength bool, ok bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false, true
+	case reflect.String:
+		return float64(len([]rune(val.String()))), true, true
+	}
+	if isLengthKind(val.Kind()) {
+		return float64(val.Len()), true, true
+	}
+	return 0, false, false
+}
+
+func boundMessage(relation, bound string, isLength bool, kind reflect.Kind) string {
+	switch {
+	case !isLength:
+		return fmt.Sprintf("must be %s %s", relation, bound)
+	case kind == reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", relation, bound)
+	}
+	return fmt.Sprintf("must have %s %s elements", relation, bound)
+}